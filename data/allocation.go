@@ -0,0 +1,9 @@
+package data
+
+// Allocation is a card claimed by a member for the current period, for example by picking it in a draft
+type Allocation struct {
+	ID       uint32
+	DomainID uint32
+	MinionID uint32
+	Task     Task
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/niven/taskmaster/util"
+)
+
+// AwayPeriod is a stretch of days a minion doesn't draw cards, in one domain or in all of them
+type AwayPeriod struct {
+	ID         uint32
+	MinionID   uint32
+	DomainID   sql.NullInt64 // all domains when not valid
+	DomainName string
+	StartsOn   time.Time
+	EndsOn     time.Time // including
+}
+
+func (a AwayPeriod) Covers(day time.Time) bool {
+	d := util.StrDateFromTime(day)
+	return util.StrDateFromTime(a.StartsOn) <= d && d <= util.StrDateFromTime(a.EndsOn)
+}
+
+func (a AwayPeriod) AppliesTo(domainID uint32) bool {
+	return !a.DomainID.Valid || a.DomainID.Int64 == int64(domainID)
+}
+
+func AwayPeriodFilter(periods []AwayPeriod, condition func(a AwayPeriod) bool) []AwayPeriod {
+
+	var result []AwayPeriod
+	for _, a := range periods {
+		if condition(a) {
+			result = append(result, a)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestAwayPeriodCovers(t *testing.T) {
+
+	start := time.Date(2019, time.February, 4, 0, 0, 0, 0, time.UTC)
+	away := AwayPeriod{StartsOn: start, EndsOn: start.AddDate(0, 0, 2)}
+
+	if away.Covers(start.AddDate(0, 0, -1)) || away.Covers(start.AddDate(0, 0, 3)) {
+		t.Fail()
+	}
+	// the end is included, whatever the time of day
+	if !away.Covers(start) || !away.Covers(start.AddDate(0, 0, 2).Add(20*time.Hour)) {
+		t.Fail()
+	}
+}
+
+func TestAwayPeriodAppliesTo(t *testing.T) {
+
+	everywhere := AwayPeriod{}
+	if !everywhere.AppliesTo(1) || !everywhere.AppliesTo(2) {
+		t.Fail()
+	}
+
+	one := AwayPeriod{DomainID: sql.NullInt64{Int64: 1, Valid: true}}
+	if !one.AppliesTo(1) || one.AppliesTo(2) {
+		t.Fail()
+	}
+}
+
+func TestMinionDrawsOn(t *testing.T) {
+
+	today := time.Date(2019, time.February, 10, 0, 0, 0, 0, time.UTC)
+
+	var minion Minion
+	if !minion.DrawsOn(today.AddDate(0, 0, -30), today) {
+		t.Fail()
+	}
+
+	minion.BackfillDays = sql.NullInt64{Int64: 3, Valid: true}
+	if !minion.DrawsOn(today.AddDate(0, 0, -3), today) || minion.DrawsOn(today.AddDate(0, 0, -4), today) {
+		t.Fail()
+	}
+
+	minion.Away = []AwayPeriod{AwayPeriod{StartsOn: today, EndsOn: today.AddDate(0, 0, 7)}}
+	if minion.DrawsOn(today, today) || !minion.DrawsOn(today.AddDate(0, 0, -1), today) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,25 @@
+package data
+
+// Bid is what a member is willing to pay in an auction to avoid a task, or to get it when Points is negative
+type Bid struct {
+	DomainID uint32
+	MinionID uint32
+	TaskID   uint32
+	Points   int
+}
+
+func (bid Bid) Avoid() bool {
+	return bid.Points > 0
+}
+
+func (bid Bid) Claim() bool {
+	return bid.Points < 0
+}
+
+// Amount is the number of points the bid is for
+func (bid Bid) Amount() int {
+	if bid.Points < 0 {
+		return -bid.Points
+	}
+	return bid.Points
+}
@@ -0,0 +1,18 @@
+package data
+
+import (
+	"testing"
+)
+
+func TestBidKind(t *testing.T) {
+
+	avoid := Bid{Points: 3}
+	if !avoid.Avoid() || avoid.Claim() || avoid.Amount() != 3 {
+		t.Fail()
+	}
+
+	claim := Bid{Points: -2}
+	if claim.Avoid() || !claim.Claim() || claim.Amount() != 2 {
+		t.Fail()
+	}
+}
@@ -1,14 +1,208 @@
 package data
 
 import (
+	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/niven/taskmaster/util"
+)
+
+type AssignmentMode string
+
+const (
+	RandomDraw AssignmentMode = "random"
+	Rotation   AssignmentMode = "rotation"
+	Draft      AssignmentMode = "draft"
+	Auction    AssignmentMode = "auction"
 )
 
+var AssignmentModes = []AssignmentMode{RandomDraw, Rotation, Draft, Auction}
+
+type OverduePolicy string
+
+const (
+	KeepOverdue     OverduePolicy = "keep"
+	OfferOverdue    OverduePolicy = "offer"
+	ReassignOverdue OverduePolicy = "reassign"
+	ReturnOverdue   OverduePolicy = "return"
+)
+
+var OverduePolicies = []OverduePolicy{KeepOverdue, OfferOverdue, ReassignOverdue, ReturnOverdue}
+
+type ResetCadence string
+
+const (
+	WeeklyReset    ResetCadence = "weekly"
+	BiweeklyReset  ResetCadence = "biweekly"
+	MonthlyReset   ResetCadence = "monthly" // on ResetDay
+	EmptyDeckReset ResetCadence = "empty"   // when there is nothing left to draw
+)
+
+var ResetCadences = []ResetCadence{WeeklyReset, BiweeklyReset, MonthlyReset, EmptyDeckReset}
+
+// ExhaustionPolicy is what happens when someone has to draw a card but the deck is empty
+type ExhaustionPolicy string
+
+const (
+	KeepEmpty     ExhaustionPolicy = "nothing"
+	ReshuffleDone ExhaustionPolicy = "done" // stashed cards stay out
+	ReshuffleAll  ExhaustionPolicy = "all"
+)
+
+var ExhaustionPolicies = []ExhaustionPolicy{KeepEmpty, ReshuffleDone, ReshuffleAll}
+
+// Visibility is who can look at a domain
+type Visibility string
+
+const (
+	PrivateDomain Visibility = "private" // members only
+	PublicDomain  Visibility = "public"  // anyone logged in who has the share link, without seeing who is in it
+)
+
+var Visibilities = []Visibility{PrivateDomain, PublicDomain}
+
+// MaxDrawsPerDay is the most cards a domain can hand out to each member every day
+const MaxDrawsPerDay = 5
+
 // Domain is a name for something that has tasks and chores
 type Domain struct {
-	ID            uint32
-	Owner         uint32
-	Name          string
-	LastResetDate time.Time
-	TaskCount     uint32
+	ID                 uint32
+	Owner              uint32
+	Name               string
+	LastResetDate      time.Time
+	TaskCount          uint32
+	Members            []DomainMember
+	RequireApproval    bool
+	Role               Role // of the Minion this Domain was loaded for
+	AssignmentMode     AssignmentMode
+	RotationPosition   uint32
+	RotationDealtUntil pq.NullTime
+	OverduePolicy      OverduePolicy
+	OverdueDays        uint32 // before the policy kicks in
+	Timezone           sql.NullString
+	OwnerTimezone      string
+	WeekStart          sql.NullInt64 // the domain has its own calendar when both of these are valid
+	Weekend            sql.NullInt64
+	OwnerWeekStart     time.Weekday
+	OwnerWeekend       util.Weekdays
+	ResetCadence       ResetCadence
+	ResetDay           uint32 // of the month, the last day for months that are too short
+	CarryStash         bool   // stashed cards stay out of the deck when it is reset
+	ExhaustionPolicy   ExhaustionPolicy
+	LastReshuffleDate  pq.NullTime
+	Description        sql.NullString
+	Icon               string // a few characters, usually an emoji
+	Colour             string // #rrggbb, or empty for the default
+	DrawsPerDay        uint32 // for every member, in all modes but rotation
+	Visibility         Visibility
+	ShareToken         sql.NullString // for the link to a public domain, not set while it is private
+}
+
+// Location is the timezone the domain's days start and end in, which is the owner's unless the domain has its own
+func (d Domain) Location() *time.Location {
+
+	if d.Timezone.Valid {
+		return util.Location(d.Timezone.String)
+	}
+
+	return util.Location(d.OwnerTimezone)
+}
+
+// HasOwnCalendar is true when the domain doesn't follow the calendar of its owner
+func (d Domain) HasOwnCalendar() bool {
+	return d.WeekStart.Valid && d.Weekend.Valid
+}
+
+// Calendar is when the domain's weeks start and which days are its weekend, which is the owner's unless the domain has its own
+func (d Domain) Calendar() util.Calendar {
+
+	if d.HasOwnCalendar() {
+		return util.Calendar{WeekStart: time.Weekday(d.WeekStart.Int64), Weekend: util.Weekdays(d.Weekend.Int64)}
+	}
+
+	return util.Calendar{WeekStart: d.OwnerWeekStart, Weekend: d.OwnerWeekend}
+}
+
+// CalendarFor is the calendar the minion sees the domain's cards in: the domain's own, or otherwise their own
+func (d Domain) CalendarFor(minion Minion) util.Calendar {
+
+	if d.HasOwnCalendar() {
+		return d.Calendar()
+	}
+
+	return minion.Calendar()
+}
+
+func (mode AssignmentMode) IsValid() bool {
+	for _, m := range AssignmentModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func (policy OverduePolicy) IsValid() bool {
+	for _, p := range OverduePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func (cadence ResetCadence) IsValid() bool {
+	for _, c := range ResetCadences {
+		if c == cadence {
+			return true
+		}
+	}
+	return false
+}
+
+func (policy ExhaustionPolicy) IsValid() bool {
+	for _, p := range ExhaustionPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func (visibility Visibility) IsValid() bool {
+	for _, v := range Visibilities {
+		if v == visibility {
+			return true
+		}
+	}
+	return false
+}
+
+// CardsPerDay is how many cards each member draws every day, at least one
+func (d Domain) CardsPerDay() int {
+
+	if d.DrawsPerDay < 1 {
+		return 1
+	}
+
+	return int(d.DrawsPerDay)
+}
+
+// ReshuffledOn is true when the deck ran out and was reshuffled on the given day
+func (d Domain) ReshuffledOn(day time.Time) bool {
+	return d.LastReshuffleDate.Valid && util.DaysBetween(d.LastReshuffleDate.Time, day) == 0
+}
+
+func DomainFilter(domains []Domain, condition func(d Domain) bool) []Domain {
+
+	var result []Domain
+	for _, d := range domains {
+		if condition(d) {
+			result = append(result, d)
+		}
+	}
+
+	return result
 }
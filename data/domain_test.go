@@ -0,0 +1,100 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestDomainFilter(t *testing.T) {
+
+	empty := DomainFilter([]Domain{}, func(d Domain) bool { return d.Role.Can(DrawCards) })
+	if len(empty) != 0 {
+		t.Fail()
+	}
+
+	single := DomainFilter([]Domain{Domain{Role: Member}, Domain{Role: Viewer}}, func(d Domain) bool { return d.Role.Can(DrawCards) })
+	if len(single) != 1 || single[0].Role != Member {
+		t.Fail()
+	}
+}
+
+func TestAssignmentModeIsValid(t *testing.T) {
+
+	if !RandomDraw.IsValid() || !Rotation.IsValid() || !Draft.IsValid() || !Auction.IsValid() {
+		t.Fail()
+	}
+
+	if AssignmentMode("lottery").IsValid() {
+		t.Fail()
+	}
+}
+
+func TestOverduePolicyIsValid(t *testing.T) {
+
+	for _, policy := range OverduePolicies {
+		if !policy.IsValid() {
+			t.Fail()
+		}
+	}
+
+	if OverduePolicy("burn").IsValid() {
+		t.Fail()
+	}
+}
+
+func TestDomainLocation(t *testing.T) {
+
+	following := Domain{OwnerTimezone: "America/New_York"}
+	if following.Location().String() != "America/New_York" {
+		t.Fail()
+	}
+
+	own := Domain{OwnerTimezone: "America/New_York", Timezone: sql.NullString{String: "Asia/Tokyo", Valid: true}}
+	if own.Location().String() != "Asia/Tokyo" {
+		t.Fail()
+	}
+}
+
+func TestExhaustionPolicyIsValid(t *testing.T) {
+
+	for _, policy := range ExhaustionPolicies {
+		if !policy.IsValid() {
+			t.Fail()
+		}
+	}
+
+	if ExhaustionPolicy("stashed").IsValid() {
+		t.Fail()
+	}
+}
+
+func TestReshuffledOn(t *testing.T) {
+
+	today := time.Date(2019, time.February, 6, 21, 0, 0, 0, time.UTC)
+
+	if (Domain{}).ReshuffledOn(today) {
+		t.Fail()
+	}
+
+	domain := Domain{LastReshuffleDate: pq.NullTime{Time: time.Date(2019, time.February, 6, 0, 0, 0, 0, time.UTC), Valid: true}}
+	if !domain.ReshuffledOn(today) || domain.ReshuffledOn(today.AddDate(0, 0, 1)) {
+		t.Fail()
+	}
+}
+
+func TestCardsPerDay(t *testing.T) {
+
+	if (Domain{}).CardsPerDay() != 1 || (Domain{DrawsPerDay: 3}).CardsPerDay() != 3 {
+		t.Fail()
+	}
+}
+
+func TestVisibilityIsValid(t *testing.T) {
+
+	if !PrivateDomain.IsValid() || !PublicDomain.IsValid() || Visibility("secret").IsValid() {
+		t.Fail()
+	}
+}
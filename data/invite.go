@@ -0,0 +1,29 @@
+package data
+
+import (
+	"time"
+)
+
+// DomainInvite lets someone join a Domain, either by following a link with the Token or by entering the Code
+type DomainInvite struct {
+	ID        uint32
+	DomainID  uint32
+	Token     string
+	Code      string
+	CreatedBy uint32
+	CreatedOn time.Time
+	ExpiresOn time.Time
+	UsesLeft  uint32
+}
+
+// JoinRequest is someone who used an invite but still needs approval from the owner
+type JoinRequest struct {
+	Minion      Minion
+	DomainID    uint32
+	RequestedOn time.Time
+}
+
+// IsValid tells if the invite can still be used
+func (invite DomainInvite) IsValid(now time.Time) bool {
+	return invite.UsesLeft > 0 && now.Before(invite.ExpiresOn)
+}
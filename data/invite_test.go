@@ -0,0 +1,30 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDomainInviteIsValid(t *testing.T) {
+
+	now := time.Date(2019, time.February, 3, 12, 0, 0, 0, time.UTC)
+
+	valid := DomainInvite{UsesLeft: 1, ExpiresOn: now.Add(time.Hour)}
+	if !valid.IsValid(now) {
+		t.Fail()
+	}
+
+	usedUp := DomainInvite{UsesLeft: 0, ExpiresOn: now.Add(time.Hour)}
+	if usedUp.IsValid(now) {
+		t.Fail()
+	}
+
+	expired := DomainInvite{UsesLeft: 3, ExpiresOn: now.Add(-time.Hour)}
+	if expired.IsValid(now) {
+		t.Fail()
+	}
+
+	if valid.IsValid(valid.ExpiresOn) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,113 @@
+package data
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotYourAssignment = errors.New("That card isn't yours")
+	ErrInvalidTransition = errors.New("That can't be done to this card anymore")
+	ErrUndoExpired       = errors.New("It's too late to undo that")
+)
+
+// Actor is who changes the status of an assignment
+type Actor int
+
+const (
+	Holder Actor = iota // whoever has the card
+	System              // overdue policies and other things the server does by itself
+)
+
+// allowed status changes and who can make them. Done, skipped and returned cards stay that way
+// until the deck is reset, except for stashed ones going back when a task loses copies.
+var transitions = map[AssignmentStatus]map[AssignmentStatus][]Actor{
+	Pending: map[AssignmentStatus][]Actor{
+		DoneAndAvailable: []Actor{Holder},
+		DoneAndStashed:   []Actor{Holder},
+		Skipped:          []Actor{Holder},
+		Snoozed:          []Actor{Holder},
+		Returned:         []Actor{Holder, System},
+	},
+	Snoozed: map[AssignmentStatus][]Actor{
+		Pending:          []Actor{Holder, System},
+		DoneAndAvailable: []Actor{Holder},
+		DoneAndStashed:   []Actor{Holder},
+		Skipped:          []Actor{Holder},
+		Snoozed:          []Actor{Holder},
+		Returned:         []Actor{Holder, System},
+	},
+	DoneAndStashed: map[AssignmentStatus][]Actor{
+		DoneAndAvailable: []Actor{System},
+	},
+}
+
+// CanTransition checks the actor is allowed to move the assignment to the status
+func (ta TaskAssignment) CanTransition(to AssignmentStatus, actor Actor) error {
+
+	for _, a := range transitions[ta.Status][to] {
+		if a == actor {
+			return nil
+		}
+	}
+
+	return ErrInvalidTransition
+}
+
+// TransitionBy returns the assignment with the new status, if the minion holds it and is allowed to make the change.
+// Moving away from snoozed forgets the date it was snoozed until.
+func (ta TaskAssignment) TransitionBy(minion Minion, to AssignmentStatus) (TaskAssignment, error) {
+
+	if !ta.MinionID.Valid || ta.MinionID.Int64 != int64(minion.ID) {
+		return ta, ErrNotYourAssignment
+	}
+
+	err := ta.CanTransition(to, Holder)
+	if err != nil {
+		return ta, err
+	}
+
+	ta.Status = to
+	if to != Snoozed {
+		ta.SnoozedUntil.Valid = false
+	}
+
+	return ta, nil
+}
+
+// undoable are the statuses a holder can take back, to the open status the card had before
+var undoable = []AssignmentStatus{DoneAndAvailable, DoneAndStashed, Skipped}
+
+// CanUndo checks the minion can take back the last status change of the assignment before the deadline
+func (ta TaskAssignment) CanUndo(minion Minion, now time.Time) error {
+
+	if !ta.MinionID.Valid || ta.MinionID.Int64 != int64(minion.ID) {
+		return ErrNotYourAssignment
+	}
+
+	if !ta.PreviousStatus.IsOpen() || !ta.StatusChangedAt.Valid {
+		return ErrInvalidTransition
+	}
+
+	for _, status := range undoable {
+		if ta.Status == status {
+			if now.After(minion.UndoDeadline(ta.StatusChangedAt.Time)) {
+				return ErrUndoExpired
+			}
+			return nil
+		}
+	}
+
+	return ErrInvalidTransition
+}
+
+// Undone returns the assignment as it was before its last status change
+func (ta TaskAssignment) Undone() TaskAssignment {
+
+	ta.Status, ta.SnoozedUntil = ta.PreviousStatus, ta.PreviousSnoozedUntil
+	ta.PreviousStatus, ta.PreviousSnoozedUntil = "", pq.NullTime{}
+
+	return ta
+}
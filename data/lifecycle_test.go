@@ -0,0 +1,127 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestCanTransition(t *testing.T) {
+
+	pending := NewTaskAssignment(Task{ID: 1}, Minion{ID: 1}, time.Now())
+
+	for _, to := range []AssignmentStatus{DoneAndAvailable, DoneAndStashed, Skipped, Snoozed, Returned} {
+		if pending.CanTransition(to, Holder) != nil {
+			t.Fail()
+		}
+	}
+
+	// the server only returns cards, it doesn't do chores
+	if pending.CanTransition(DoneAndAvailable, System) != ErrInvalidTransition || pending.CanTransition(Returned, System) != nil {
+		t.Fail()
+	}
+
+	// done is done
+	done := pending
+	done.Status = DoneAndStashed
+	for _, to := range []AssignmentStatus{Pending, DoneAndAvailable, DoneAndStashed, Skipped, Snoozed, Returned} {
+		if done.CanTransition(to, Holder) != ErrInvalidTransition {
+			t.Fail()
+		}
+	}
+
+	// unless its task loses copies
+	if done.CanTransition(DoneAndAvailable, System) != nil {
+		t.Fail()
+	}
+}
+
+func TestTransitionBy(t *testing.T) {
+
+	gru := Minion{ID: 1}
+	snoozed := NewTaskAssignment(Task{ID: 1}, gru, time.Now())
+	snoozed.Status = Snoozed
+	snoozed.SnoozedUntil = pq.NullTime{Time: time.Now().AddDate(0, 0, 2), Valid: true}
+
+	if _, err := snoozed.TransitionBy(Minion{ID: 2}, DoneAndAvailable); err != ErrNotYourAssignment {
+		t.Fail()
+	}
+
+	done, err := snoozed.TransitionBy(gru, DoneAndAvailable)
+	if err != nil || done.Status != DoneAndAvailable || done.SnoozedUntil.Valid {
+		t.Fail()
+	}
+
+	if _, err := done.TransitionBy(gru, DoneAndStashed); err != ErrInvalidTransition {
+		t.Fail()
+	}
+
+	// nobody holds a card that was put back in the deck
+	returned := NewTaskAssignment(Task{ID: 1}, gru, time.Now())
+	returned.MinionID.Valid = false
+	if _, err := returned.TransitionBy(gru, Skipped); err != ErrNotYourAssignment {
+		t.Fail()
+	}
+}
+
+func TestUndoDeadline(t *testing.T) {
+
+	changedAt := time.Date(2018, 3, 14, 22, 30, 0, 0, time.UTC)
+
+	gru := Minion{ID: 1, Timezone: "UTC", UndoMinutes: sql.NullInt64{Int64: 10, Valid: true}}
+	if !gru.UndoDeadline(changedAt).Equal(changedAt.Add(10 * time.Minute)) {
+		t.Fail()
+	}
+
+	// until midnight where the minion lives
+	gru.UndoMinutes.Valid = false
+	if !gru.UndoDeadline(changedAt).Equal(time.Date(2018, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fail()
+	}
+}
+
+func TestCanUndo(t *testing.T) {
+
+	gru := Minion{ID: 1, Timezone: "UTC", UndoMinutes: sql.NullInt64{Int64: 10, Valid: true}}
+	now := time.Now()
+
+	pending := NewTaskAssignment(Task{ID: 1}, gru, now)
+	pending.SnoozedUntil = pq.NullTime{Time: now.AddDate(0, 0, 2), Valid: true}
+	pending.Status = Snoozed
+
+	// nothing to take back yet
+	if pending.CanUndo(gru, now) != ErrInvalidTransition {
+		t.Fail()
+	}
+
+	done, err := pending.TransitionBy(gru, DoneAndAvailable)
+	if err != nil {
+		t.Fail()
+	}
+	done.PreviousStatus, done.PreviousSnoozedUntil = pending.Status, pending.SnoozedUntil
+	done.StatusChangedAt = pq.NullTime{Time: now, Valid: true}
+
+	if done.CanUndo(gru, now.Add(5*time.Minute)) != nil {
+		t.Fail()
+	}
+	if done.CanUndo(Minion{ID: 2}, now) != ErrNotYourAssignment {
+		t.Fail()
+	}
+	if done.CanUndo(gru, now.Add(15*time.Minute)) != ErrUndoExpired {
+		t.Fail()
+	}
+
+	undone := done.Undone()
+	if undone.Status != Snoozed || !undone.SnoozedUntil.Valid || undone.PreviousStatus != "" || undone.CanUndo(gru, now) != ErrInvalidTransition {
+		t.Fail()
+	}
+
+	// returned cards go back through the server, not undo
+	returned := done
+	returned.Status = Returned
+	if returned.CanUndo(gru, now) != ErrInvalidTransition {
+		t.Fail()
+	}
+}
@@ -1,8 +1,77 @@
 package data
 
+import (
+	"database/sql"
+	"time"
+
+	"github.com/niven/taskmaster/util"
+)
+
 // Minion is someone who performs tasks in Domains
 type Minion struct {
-	ID    uint32
-	Email string
-	Name  string
+	ID           uint32
+	Email        string
+	Name         string
+	Timezone     string // IANA name, like Europe/Amsterdam
+	WeekStart    time.Weekday
+	Weekend      util.Weekdays
+	BackfillDays sql.NullInt64 // missed days further back don't get a card, no limit when not valid
+	UndoMinutes  sql.NullInt64 // to take back finishing a card, until the end of the day when not valid
+	Away         []AwayPeriod  // when loaded
+}
+
+// Location is the timezone the minion's days start and end in
+func (m Minion) Location() *time.Location {
+	return util.Location(m.Timezone)
+}
+
+// Calendar is when the minion's weeks start and which days are their weekend
+func (m Minion) Calendar() util.Calendar {
+	return util.Calendar{WeekStart: m.WeekStart, Weekend: m.Weekend}
+}
+
+// IsAwayOn is true when one of the minion's away periods covers the day
+func (m Minion) IsAwayOn(day time.Time) bool {
+
+	for _, a := range m.Away {
+		if a.Covers(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DrawsOn says whether the minion gets a card for a day: not while they are away, and not for missed
+// days further back than they want filled in
+func (m Minion) DrawsOn(day, today time.Time) bool {
+
+	if m.IsAwayOn(day) {
+		return false
+	}
+
+	return !m.BackfillDays.Valid || util.DaysBetween(day, today) <= int(m.BackfillDays.Int64)
+}
+
+// UndoDeadline is until when a status change the minion made at a time can be taken back
+func (m Minion) UndoDeadline(changedAt time.Time) time.Time {
+
+	if m.UndoMinutes.Valid {
+		return changedAt.Add(time.Duration(m.UndoMinutes.Int64) * time.Minute)
+	}
+
+	y, mm, d := changedAt.In(m.Location()).Date()
+	return time.Date(y, mm, d+1, 0, 0, 0, 0, m.Location())
+}
+
+func MinionFilter(minions []Minion, condition func(m Minion) bool) []Minion {
+
+	var result []Minion
+	for _, m := range minions {
+		if condition(m) {
+			result = append(result, m)
+		}
+	}
+
+	return result
 }
@@ -0,0 +1,27 @@
+package data
+
+import (
+	"database/sql"
+	"time"
+)
+
+type MoveReason string
+
+const (
+	MovedBySwap     MoveReason = "swap"
+	MovedByOffer    MoveReason = "offer"
+	MovedByReassign MoveReason = "reassign"
+	MovedByReturn   MoveReason = "return"
+	MovedBySystem   MoveReason = "system" // back in the deck without anyone doing so, like when a task has fewer copies
+)
+
+// AssignmentMove records a card changing hands, or going back into the deck when To is not set
+type AssignmentMove struct {
+	ID           uint32
+	AssignmentID uint32
+	TaskName     string
+	From         Minion
+	To           sql.NullInt64
+	Reason       MoveReason
+	MovedOn      time.Time
+}
@@ -0,0 +1,33 @@
+package data
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Offer is a member asking someone else to take over one of their assignments,
+// either in exchange for one of theirs or for a bounty in points
+type Offer struct {
+	ID         uint32
+	DomainID   uint32
+	Assignment TaskAssignment
+	OfferedBy  Minion
+	OfferedTo  sql.NullInt64  // only for swaps, anyone can take a bounty
+	SwapFor    TaskAssignment // ID is 0 when this is not a swap
+	Bounty     int
+	ExpiresOn  time.Time
+}
+
+func (offer Offer) IsSwap() bool {
+	return offer.SwapFor.ID != 0
+}
+
+// IsFor says if the minion is the one that can accept the offer
+func (offer Offer) IsFor(minion Minion) bool {
+
+	if offer.OfferedBy.ID == minion.ID {
+		return false
+	}
+
+	return !offer.OfferedTo.Valid || offer.OfferedTo.Int64 == int64(minion.ID)
+}
@@ -0,0 +1,23 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestOfferIsFor(t *testing.T) {
+
+	gru := Minion{ID: 1}
+	kevin := Minion{ID: 2}
+	bob := Minion{ID: 3}
+
+	bounty := Offer{OfferedBy: gru, Bounty: 3}
+	if bounty.IsSwap() || bounty.IsFor(gru) || !bounty.IsFor(kevin) || !bounty.IsFor(bob) {
+		t.Fail()
+	}
+
+	swap := Offer{OfferedBy: gru, OfferedTo: sql.NullInt64{Int64: 2, Valid: true}, SwapFor: TaskAssignment{ID: 7}}
+	if !swap.IsSwap() || swap.IsFor(gru) || !swap.IsFor(kevin) || swap.IsFor(bob) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,64 @@
+package data
+
+// Role is what a member is allowed to do in a Domain
+type Role string
+
+const (
+	Owner  Role = "owner"
+	Admin  Role = "admin"
+	Member Role = "member"
+	Viewer Role = "viewer"
+)
+
+// AssignableRoles can be handed out to members, becoming Owner only happens through a transfer
+var AssignableRoles = []Role{Admin, Member, Viewer}
+
+type Permission int
+
+const (
+	ViewBoard Permission = iota
+	DrawCards
+	EditTasks
+	ManageMembers
+	ManageDomain
+)
+
+var rolePermissions = map[Role][]Permission{
+	Owner:  []Permission{ViewBoard, DrawCards, EditTasks, ManageMembers, ManageDomain},
+	Admin:  []Permission{ViewBoard, DrawCards, EditTasks, ManageMembers},
+	Member: []Permission{ViewBoard, DrawCards},
+	Viewer: []Permission{ViewBoard},
+}
+
+var roleRank = map[Role]int{
+	Owner:  4,
+	Admin:  3,
+	Member: 2,
+	Viewer: 1,
+}
+
+// DomainMember is a Minion as part of a Domain
+type DomainMember struct {
+	Minion
+	Role   Role
+	Points int // earned by doing chores, spent in auctions
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, permission := range rolePermissions[r] {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks tells if r is higher than other, so admins can't demote or remove each other (or the owner)
+func (r Role) Outranks(other Role) bool {
+	return roleRank[r] > roleRank[other]
+}
+
+func (r Role) IsValid() bool {
+	_, exists := roleRank[r]
+	return exists
+}
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"testing"
+)
+
+func TestRoleCan(t *testing.T) {
+
+	if !Owner.Can(ManageDomain) || !Owner.Can(DrawCards) {
+		t.Fail()
+	}
+
+	if Admin.Can(ManageDomain) || !Admin.Can(EditTasks) || !Admin.Can(ManageMembers) {
+		t.Fail()
+	}
+
+	if Member.Can(EditTasks) || !Member.Can(DrawCards) {
+		t.Fail()
+	}
+
+	if Viewer.Can(DrawCards) || !Viewer.Can(ViewBoard) {
+		t.Fail()
+	}
+
+	if Role("").Can(ViewBoard) {
+		t.Fail()
+	}
+}
+
+func TestRoleOutranks(t *testing.T) {
+
+	if !Owner.Outranks(Admin) || !Admin.Outranks(Member) || !Member.Outranks(Viewer) {
+		t.Fail()
+	}
+
+	if Admin.Outranks(Admin) || Admin.Outranks(Owner) || Viewer.Outranks(Member) {
+		t.Fail()
+	}
+}
+
+func TestRoleIsValid(t *testing.T) {
+
+	for _, role := range []Role{Owner, Admin, Member, Viewer} {
+		if !role.IsValid() {
+			t.Fail()
+		}
+	}
+
+	if Role("janitor").IsValid() || Role("").IsValid() {
+		t.Fail()
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+
+	"github.com/niven/taskmaster/util"
 )
 
 type AssignmentStatus string
@@ -13,8 +15,25 @@ const (
 	Pending          AssignmentStatus = "pending"
 	DoneAndAvailable AssignmentStatus = "done_and_available"
 	DoneAndStashed   AssignmentStatus = "done_and_stashed"
+	Returned         AssignmentStatus = "returned" // back in the deck without being done
+	Skipped          AssignmentStatus = "skipped"  // back in the deck by whoever had it, nothing else for that day
+	Snoozed          AssignmentStatus = "snoozed"  // moved to SnoozedUntil
 )
 
+// IsOpen is true for the statuses of cards that still have to be done
+func (status AssignmentStatus) IsOpen() bool {
+	return status == Pending || status == Snoozed
+}
+
+func (status AssignmentStatus) IsDone() bool {
+	return status == DoneAndAvailable || status == DoneAndStashed
+}
+
+// HoldsCopy is true for the statuses of cards that keep a copy of their task out of the deck
+func (status AssignmentStatus) HoldsCopy() bool {
+	return status.IsOpen() || status == DoneAndStashed
+}
+
 // Task is a chore you do
 type Task struct {
 	ID          uint32
@@ -23,6 +42,15 @@ type Task struct {
 	Weekly      bool
 	Count       uint32
 	Description sql.NullString
+	Tags        pq.StringArray
+}
+
+// StoredTags are the tags as they go into the database, where a task without tags has an empty list and not NULL
+func (t Task) StoredTags() pq.StringArray {
+	if t.Tags == nil {
+		return pq.StringArray{}
+	}
+	return t.Tags
 }
 
 var NoTask = Task{
@@ -37,6 +65,11 @@ type TaskAssignment struct {
 	AssignedDate pq.NullTime
 	AgeInDays    uint32
 	Status       AssignmentStatus
+	SnoozedUntil pq.NullTime
+	// before the last status change, for undoing it
+	PreviousStatus       AssignmentStatus // empty if there is nothing to undo
+	PreviousSnoozedUntil pq.NullTime
+	StatusChangedAt      pq.NullTime
 }
 
 func NewTaskAssignment(task Task, minion Minion, time time.Time) TaskAssignment {
@@ -45,11 +78,27 @@ func NewTaskAssignment(task Task, minion Minion, time time.Time) TaskAssignment
 		Task:         task,
 		MinionID:     sql.NullInt64{Int64: int64(minion.ID), Valid: true},
 		AssignedDate: pq.NullTime{Time: time, Valid: true},
+		Status:       Pending,
 	}
 
 	return result
 }
 
+// DueDate is the last day to do the assignment before it is overdue: the day it was assigned,
+// or the first weekend day of that week for weekly ones. Snoozed ones are due on the day they were moved to.
+func (ta TaskAssignment) DueDate(calendar util.Calendar) time.Time {
+
+	if ta.Status == Snoozed && ta.SnoozedUntil.Valid {
+		return ta.SnoozedUntil.Time
+	}
+
+	if !ta.Task.Weekly {
+		return ta.AssignedDate.Time
+	}
+
+	return calendar.WeeklyDueDate(ta.AssignedDate.Time)
+}
+
 // both these are the same, but no generics...
 
 func TaskAssignmentFilter(assignments []TaskAssignment, condition func(t TaskAssignment) bool) []TaskAssignment {
@@ -3,6 +3,10 @@ package data
 import (
 	"testing"
 	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/niven/taskmaster/util"
 )
 
 func TestNewTaskAssignment(t *testing.T) {
@@ -49,3 +53,63 @@ func TestTaskFilter(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestStoredTags(t *testing.T) {
+
+	// the tags column is NOT NULL, so no tags has to be stored as {}
+	value, err := Task{}.StoredTags().Value()
+	if err != nil || value != "{}" {
+		t.Error(value, err)
+	}
+
+	value, err = Task{Tags: pq.StringArray{"kitchen"}}.StoredTags().Value()
+	if err != nil || value != `{"kitchen"}` {
+		t.Error(value, err)
+	}
+}
+
+func TestDueDate(t *testing.T) {
+
+	wednesday := time.Date(2019, time.February, 6, 0, 0, 0, 0, time.UTC)
+
+	daily := NewTaskAssignment(Task{ID: 1}, Minion{ID: 1}, wednesday)
+	if !daily.DueDate(util.DefaultCalendar).Equal(wednesday) {
+		t.Fail()
+	}
+
+	weekly := NewTaskAssignment(Task{ID: 2, Weekly: true}, Minion{ID: 1}, wednesday)
+	if weekly.DueDate(util.DefaultCalendar).Weekday() != time.Saturday || weekly.DueDate(util.DefaultCalendar).Day() != 9 {
+		t.Fail()
+	}
+
+	// with a friday/saturday weekend it is due a day earlier
+	calendar := util.Calendar{WeekStart: time.Sunday, Weekend: util.NewWeekdays(time.Friday, time.Saturday)}
+	if weekly.DueDate(calendar).Weekday() != time.Friday || weekly.DueDate(calendar).Day() != 8 {
+		t.Fail()
+	}
+}
+
+func TestAssignmentStatusIsOpen(t *testing.T) {
+
+	if !Pending.IsOpen() || !Snoozed.IsOpen() {
+		t.Fail()
+	}
+
+	if DoneAndAvailable.IsOpen() || DoneAndStashed.IsOpen() || Returned.IsOpen() || Skipped.IsOpen() {
+		t.Fail()
+	}
+}
+
+func TestSnoozedDueDate(t *testing.T) {
+
+	wednesday := time.Date(2019, time.February, 6, 0, 0, 0, 0, time.UTC)
+	friday := wednesday.AddDate(0, 0, 2)
+
+	snoozed := NewTaskAssignment(Task{ID: 1}, Minion{ID: 1}, wednesday)
+	snoozed.Status = Snoozed
+	snoozed.SnoozedUntil = pq.NullTime{Time: friday, Valid: true}
+
+	if !snoozed.DueDate(util.DefaultCalendar).Equal(friday) {
+		t.Fail()
+	}
+}
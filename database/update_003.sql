@@ -0,0 +1,6 @@
+-- Shared domains: every member (including the owner) gets a row in minion_domain
+ALTER TABLE minion_domain ADD COLUMN joined_on TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE minion_domain ADD CONSTRAINT minion_domain_pkey PRIMARY KEY (minion_id, domain_id);
+-- existing domains only have an owner
+INSERT INTO minion_domain (minion_id, domain_id) SELECT owner, id FROM domains WHERE id != 0 ON CONFLICT DO NOTHING;
+INSERT INTO version (point) VALUES (3);
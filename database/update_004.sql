@@ -0,0 +1,6 @@
+-- Invitations to join a domain, either as a link with a token or as a short code to type in
+CREATE TABLE domain_invites (id SERIAL PRIMARY KEY, domain_id INTEGER NOT NULL, token VARCHAR(64) NOT NULL UNIQUE, code VARCHAR(16) NOT NULL UNIQUE, created_by INTEGER NOT NULL, created_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, expires_on TIMESTAMPTZ NOT NULL, uses_left INTEGER NOT NULL DEFAULT 1, CONSTRAINT domain_invites_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains ON DELETE CASCADE, CONSTRAINT domain_invites_created_by_ref_minions_id_fkey FOREIGN KEY (created_by) REFERENCES minions(id));
+-- People who used an invite for a domain where the owner has to let them in
+CREATE TABLE join_requests (minion_id INTEGER, domain_id INTEGER, requested_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT join_requests_pkey PRIMARY KEY (minion_id, domain_id), CONSTRAINT join_requests_minion_id_ref_minions_id_fkey FOREIGN KEY (minion_id) REFERENCES minions(id), CONSTRAINT join_requests_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains ON DELETE CASCADE);
+ALTER TABLE domains ADD COLUMN require_approval BOOLEAN NOT NULL DEFAULT false;
+INSERT INTO version (point) VALUES (4);
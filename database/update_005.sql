@@ -0,0 +1,5 @@
+-- Roles for the members of a domain
+CREATE TYPE enum_role AS ENUM ('owner', 'admin', 'member', 'viewer');
+ALTER TABLE minion_domain ADD COLUMN role enum_role NOT NULL DEFAULT 'member';
+UPDATE minion_domain SET role = 'owner' FROM domains WHERE domains.id = minion_domain.domain_id AND domains.owner = minion_domain.minion_id;
+INSERT INTO version (point) VALUES (5);
@@ -0,0 +1,7 @@
+-- How cards are handed out: drawn at random, or rotated between members like a chore wheel
+CREATE TYPE enum_assignment_mode AS ENUM ('random', 'rotation');
+ALTER TABLE domains ADD COLUMN assignment_mode enum_assignment_mode NOT NULL DEFAULT 'random';
+-- rotation state lives on the domain so it survives resets
+ALTER TABLE domains ADD COLUMN rotation_position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE domains ADD COLUMN rotation_dealt_until DATE;
+INSERT INTO version (point) VALUES (6);
@@ -0,0 +1,6 @@
+-- Draft mode: members take turns picking cards from the deck at the start of each period
+ALTER TYPE enum_assignment_mode ADD VALUE 'draft';
+-- Cards claimed by a member for the current period, the id gives the order they were picked in
+CREATE TABLE domain_allocations (id SERIAL PRIMARY KEY, domain_id INTEGER NOT NULL, minion_id INTEGER NOT NULL, task_id INTEGER NOT NULL, allocated_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT domain_allocations_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains ON DELETE CASCADE, CONSTRAINT domain_allocations_minion_id_ref_minions_id_fkey FOREIGN KEY (minion_id) REFERENCES minions(id), CONSTRAINT domain_allocations_task_id_ref_tasks_id_fkey_del_cascade FOREIGN KEY (task_id) REFERENCES tasks ON DELETE CASCADE);
+CREATE INDEX domain_allocations_domain_id_minion_id ON domain_allocations (domain_id, minion_id);
+INSERT INTO version (point) VALUES (7);
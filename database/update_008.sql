@@ -0,0 +1,7 @@
+-- Auction mode: members bid points to avoid or claim tasks, the cards are handed out at the start of each period
+ALTER TYPE enum_assignment_mode ADD VALUE 'auction';
+-- points are earned by doing chores in a domain and spent in its auctions
+ALTER TABLE minion_domain ADD COLUMN points INTEGER NOT NULL DEFAULT 0;
+-- positive points to avoid the task, negative points to claim it
+CREATE TABLE domain_bids (domain_id INTEGER, minion_id INTEGER, task_id INTEGER, points INTEGER NOT NULL, CONSTRAINT domain_bids_pkey PRIMARY KEY (domain_id, minion_id, task_id), CONSTRAINT domain_bids_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains ON DELETE CASCADE, CONSTRAINT domain_bids_minion_id_ref_minions_id_fkey FOREIGN KEY (minion_id) REFERENCES minions(id), CONSTRAINT domain_bids_task_id_ref_tasks_id_fkey_del_cascade FOREIGN KEY (task_id) REFERENCES tasks ON DELETE CASCADE);
+INSERT INTO version (point) VALUES (8);
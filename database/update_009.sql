@@ -0,0 +1,6 @@
+-- Who drew a card, which stays the same when it is handed to someone else so their day doesn't get filled with a new one
+ALTER TABLE task_assignments ADD COLUMN drawn_by INTEGER;
+UPDATE task_assignments SET drawn_by = minion_id;
+-- Offers to hand a card to another member: a swap for one of theirs, or a bounty in points for whoever takes it
+CREATE TABLE assignment_offers (id SERIAL PRIMARY KEY, domain_id INTEGER NOT NULL, assignment_id INTEGER NOT NULL UNIQUE, offered_by INTEGER NOT NULL, offered_to INTEGER, swap_for INTEGER, bounty INTEGER NOT NULL DEFAULT 0, created_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, expires_on DATE NOT NULL, CONSTRAINT assignment_offers_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains ON DELETE CASCADE, CONSTRAINT assignment_offers_assignment_id_ref_task_assignments_id_fkey_del_cascade FOREIGN KEY (assignment_id) REFERENCES task_assignments ON DELETE CASCADE, CONSTRAINT assignment_offers_swap_for_ref_task_assignments_id_fkey_del_cascade FOREIGN KEY (swap_for) REFERENCES task_assignments ON DELETE CASCADE, CONSTRAINT assignment_offers_offered_by_ref_minions_id_fkey FOREIGN KEY (offered_by) REFERENCES minions(id), CONSTRAINT assignment_offers_offered_to_ref_minions_id_fkey FOREIGN KEY (offered_to) REFERENCES minions(id));
+INSERT INTO version (point) VALUES (9);
@@ -0,0 +1,10 @@
+-- What happens to cards that stay overdue in a shared domain: kept, offered to the others, reassigned or returned to the deck
+CREATE TYPE enum_overdue_policy AS ENUM ('keep', 'offer', 'reassign', 'return');
+ALTER TABLE domains ADD COLUMN overdue_policy enum_overdue_policy NOT NULL DEFAULT 'keep';
+ALTER TABLE domains ADD COLUMN overdue_days INTEGER NOT NULL DEFAULT 3;
+-- a card that went back into the deck without being done
+ALTER TYPE enum_status ADD VALUE 'returned';
+-- History of cards changing hands
+CREATE TYPE enum_move_reason AS ENUM ('swap', 'offer', 'reassign', 'return');
+CREATE TABLE assignment_moves (id SERIAL PRIMARY KEY, assignment_id INTEGER NOT NULL, from_minion INTEGER NOT NULL, to_minion INTEGER, reason enum_move_reason NOT NULL, moved_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT assignment_moves_assignment_id_ref_task_assignments_id_fkey_del_cascade FOREIGN KEY (assignment_id) REFERENCES task_assignments ON DELETE CASCADE, CONSTRAINT assignment_moves_from_minion_ref_minions_id_fkey FOREIGN KEY (from_minion) REFERENCES minions(id), CONSTRAINT assignment_moves_to_minion_ref_minions_id_fkey FOREIGN KEY (to_minion) REFERENCES minions(id));
+INSERT INTO version (point) VALUES (10);
@@ -0,0 +1,5 @@
+-- Timezones, so everyone's day starts at their own midnight
+ALTER TABLE minions ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+-- a domain can have its own timezone, otherwise it follows its owner
+ALTER TABLE domains ADD COLUMN timezone VARCHAR(64);
+INSERT INTO version (point) VALUES (11);
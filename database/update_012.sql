@@ -0,0 +1,7 @@
+-- The day weeks start on (0 is Sunday) and the weekend days as a bitmask with bit 0 for Sunday, Saturday and Sunday by default
+ALTER TABLE minions ADD COLUMN week_start SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE minions ADD COLUMN weekend SMALLINT NOT NULL DEFAULT 65;
+-- a domain can have its own, otherwise it follows its owner
+ALTER TABLE domains ADD COLUMN week_start SMALLINT;
+ALTER TABLE domains ADD COLUMN weekend SMALLINT;
+INSERT INTO version (point) VALUES (12);
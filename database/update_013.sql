@@ -0,0 +1,6 @@
+-- How often stashed and returned cards are shuffled back into the deck, and whether stashed cards survive it
+CREATE TYPE enum_reset_cadence AS ENUM ('weekly', 'biweekly', 'monthly', 'empty');
+ALTER TABLE domains ADD COLUMN reset_cadence enum_reset_cadence NOT NULL DEFAULT 'monthly';
+ALTER TABLE domains ADD COLUMN reset_day SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE domains ADD COLUMN carry_stash BOOLEAN NOT NULL DEFAULT false;
+INSERT INTO version (point) VALUES (13);
@@ -0,0 +1,5 @@
+-- What happens when someone has to draw from an empty deck: nothing, done cards go back in, or stashed ones too
+CREATE TYPE enum_exhaustion_policy AS ENUM ('nothing', 'done', 'all');
+ALTER TABLE domains ADD COLUMN exhaustion_policy enum_exhaustion_policy NOT NULL DEFAULT 'nothing';
+ALTER TABLE domains ADD COLUMN last_reshuffle_date DATE;
+INSERT INTO version (point) VALUES (14);
@@ -0,0 +1,5 @@
+-- Days a minion doesn't draw cards, in one domain or in all of them when domain_id is NULL
+CREATE TABLE away_periods (id SERIAL PRIMARY KEY, minion_id INTEGER NOT NULL, domain_id INTEGER, starts_on DATE NOT NULL, ends_on DATE NOT NULL, CONSTRAINT away_periods_minion_id_ref_minions_id_fkey_del_cascade FOREIGN KEY (minion_id) REFERENCES minions(id) ON DELETE CASCADE, CONSTRAINT away_periods_domain_id_ref_domains_id_fkey_del_cascade FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE);
+-- how many days back missed cards are still drawn, NULL for all of them
+ALTER TABLE minions ADD COLUMN backfill_days INTEGER;
+INSERT INTO version (point) VALUES (15);
@@ -0,0 +1,5 @@
+-- Put a card back without doing it, or move it to a later day
+ALTER TYPE enum_status ADD VALUE 'skipped';
+ALTER TYPE enum_status ADD VALUE 'snoozed';
+ALTER TABLE task_assignments ADD COLUMN snoozed_until DATE;
+INSERT INTO version (point) VALUES (16);
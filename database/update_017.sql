@@ -0,0 +1,7 @@
+-- What an assignment was before its last status change, so finishing a card by accident can be undone
+ALTER TABLE task_assignments ADD COLUMN previous_status enum_status;
+ALTER TABLE task_assignments ADD COLUMN previous_snoozed_until DATE;
+ALTER TABLE task_assignments ADD COLUMN status_changed_at TIMESTAMPTZ;
+-- how long that can be done, NULL for until the end of the day
+ALTER TABLE minions ADD COLUMN undo_minutes INTEGER DEFAULT 10;
+INSERT INTO version (point) VALUES (17);
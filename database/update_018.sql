@@ -0,0 +1,8 @@
+-- What a deck looks like and how many cards it hands out, and whether people outside it can look at it
+ALTER TABLE domains ADD COLUMN description TEXT;
+ALTER TABLE domains ADD COLUMN icon VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE domains ADD COLUMN colour VARCHAR(7) NOT NULL DEFAULT '';
+ALTER TABLE domains ADD COLUMN draws_per_day SMALLINT NOT NULL DEFAULT 1;
+CREATE TYPE enum_visibility AS ENUM ('private', 'public');
+ALTER TABLE domains ADD COLUMN visibility enum_visibility NOT NULL DEFAULT 'private';
+INSERT INTO version (point) VALUES (18);
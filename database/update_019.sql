@@ -0,0 +1,3 @@
+-- Tags to group the tasks of a deck, like the room they are in
+ALTER TABLE tasks ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+INSERT INTO version (point) VALUES (19);
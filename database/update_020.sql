@@ -0,0 +1,3 @@
+-- Cards the system puts back in the deck, like when a task has fewer copies than are drawn
+ALTER TYPE enum_move_reason ADD VALUE 'system';
+INSERT INTO version (point) VALUES (20);
@@ -0,0 +1,5 @@
+-- Public decks are shared with a link that can't be guessed, instead of being open to anyone who knows the ID
+ALTER TABLE domains ADD COLUMN share_token VARCHAR(64) UNIQUE;
+-- their owners have to make them public again to get a link
+UPDATE domains SET visibility = 'private' WHERE visibility = 'public';
+INSERT INTO version (point) VALUES (21);
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// Allocations returns every card claimed in this domain for the current period, in the order they were claimed
+func (dtx *DomainTx) Allocations() ([]Allocation, error) {
+	return getAllocationsForDomain(dtx.tx, dtx.Domain)
+}
+
+func GetAllocationsForDomain(domain Domain) ([]Allocation, error) {
+	return getAllocationsForDomain(db, domain)
+}
+
+func getAllocationsForDomain(q querier, domain Domain) ([]Allocation, error) {
+
+	rows, err := q.Query("SELECT a.id, a.domain_id, a.minion_id, t.id, t.domain_id, t.name, t.weekly, t.description, t.tags FROM domain_allocations a JOIN tasks t ON a.task_id = t.id WHERE a.domain_id = $1 ORDER BY a.id", domain.ID)
+	if err != nil {
+		log.Printf("Error reading allocations: %q", err)
+		return nil, err
+	}
+
+	var result []Allocation
+
+	defer rows.Close()
+	for rows.Next() {
+		var a Allocation
+
+		if err := rows.Scan(&a.ID, &a.DomainID, &a.MinionID, &a.Task.ID, &a.Task.DomainID, &a.Task.Name, &a.Task.Weekly, &a.Task.Description, &a.Task.Tags); err != nil {
+			log.Printf("Error scanning allocation: %q", err)
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// HasAllocations says if the cards for this period have been handed out already
+func (dtx *DomainTx) HasAllocations() (bool, error) {
+
+	var exists bool
+	row := dtx.tx.QueryRow("SELECT EXISTS (SELECT 1 FROM domain_allocations WHERE domain_id = $1)", dtx.Domain.ID)
+	err := row.Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking allocations: %q", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (dtx *DomainTx) AllocationInsert(minion Minion, task Task) error {
+
+	_, err := dtx.tx.Exec("INSERT INTO domain_allocations (domain_id, minion_id, task_id) VALUES($1, $2, $3)", dtx.Domain.ID, minion.ID, task.ID)
+	if err != nil {
+		log.Printf("Error inserting allocation: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// AllocatedTasks returns the cards the minion claimed that haven't been drawn yet this period,
+// with Count set to the number of copies left. It is what the deck looks like for that minion.
+func (dtx *DomainTx) AllocatedTasks(minion Minion) ([]Task, error) {
+
+	var result []Task
+
+	rows, err := dtx.tx.Query("SELECT t.id, t.domain_id, t.name, t.weekly, t.description, t.tags, a.claimed - COALESCE(ta.used, 0) AS available FROM tasks t JOIN (SELECT task_id, COUNT(*) AS claimed FROM domain_allocations WHERE domain_id = $1 AND minion_id = $2 GROUP BY task_id) a ON a.task_id = t.id LEFT JOIN (SELECT task_id, COUNT(*) AS used FROM task_assignments WHERE drawn_by = $2 AND status NOT IN ('done_and_available', 'returned', 'skipped') GROUP BY task_id) ta ON ta.task_id = t.id WHERE a.claimed > COALESCE(ta.used, 0)", dtx.Domain.ID, minion.ID)
+	if err != nil {
+		log.Printf("Error reading allocated tasks: %q", err)
+		return result, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var t Task
+		// results of math ops in postgres end up as int64 columns
+		var taskCount int64
+
+		if err := rows.Scan(&t.ID, &t.DomainID, &t.Name, &t.Weekly, &t.Description, &t.Tags, &taskCount); err != nil {
+			log.Printf("Error scanning task: %q", err)
+			return result, err
+		}
+		if taskCount < 0 {
+			log.Printf("Allocated count below 0 (%d) for domain %d\n", taskCount, dtx.Domain.ID)
+			return result, errors.New("DB state fail")
+		}
+		t.Count = uint32(taskCount)
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// UndrawnAllocations returns how many of the claimed copies of each task are not in the hand of whoever
+// claimed them. Those are still in the deck, but only for them.
+func (dtx *DomainTx) UndrawnAllocations() (map[uint32]uint32, error) {
+
+	result := make(map[uint32]uint32)
+
+	rows, err := dtx.tx.Query("SELECT a.task_id, SUM(GREATEST(0, a.claimed - COALESCE(ta.used, 0))) AS undrawn FROM (SELECT task_id, minion_id, COUNT(*) AS claimed FROM domain_allocations WHERE domain_id = $1 GROUP BY task_id, minion_id) a LEFT JOIN (SELECT task_id, drawn_by, COUNT(*) AS used FROM task_assignments WHERE status NOT IN ('done_and_available', 'returned', 'skipped') GROUP BY task_id, drawn_by) ta ON ta.task_id = a.task_id AND ta.drawn_by = a.minion_id GROUP BY a.task_id", dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error reading undrawn allocations: %q", err)
+		return result, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var taskID uint32
+		var undrawn int64
+
+		if err := rows.Scan(&taskID, &undrawn); err != nil {
+			log.Printf("Error scanning allocation: %q", err)
+			return result, err
+		}
+		result[taskID] = uint32(undrawn)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+// GetAwayPeriods returns all away periods of the minion, in every domain
+func GetAwayPeriods(minion Minion) ([]AwayPeriod, error) {
+
+	rows, err := db.Query("SELECT a.id, a.minion_id, a.domain_id, COALESCE(d.name, ''), a.starts_on, a.ends_on FROM away_periods a LEFT JOIN domains d ON d.id = a.domain_id WHERE a.minion_id = $1 ORDER BY a.starts_on, a.id", minion.ID)
+	if err != nil {
+		log.Printf("Error reading away periods: %q", err)
+		return nil, err
+	}
+
+	return readAwayPeriodsFromRows(rows)
+}
+
+// AwayPeriods returns the away periods of all members that apply to this domain
+func (dtx *DomainTx) AwayPeriods() ([]AwayPeriod, error) {
+
+	rows, err := dtx.tx.Query("SELECT a.id, a.minion_id, a.domain_id, '', a.starts_on, a.ends_on FROM away_periods a JOIN minion_domain md ON md.minion_id = a.minion_id AND md.domain_id = $1 WHERE a.domain_id = $1 OR a.domain_id IS NULL", dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error reading away periods: %q", err)
+		return nil, err
+	}
+
+	return readAwayPeriodsFromRows(rows)
+}
+
+func readAwayPeriodsFromRows(rows *sql.Rows) ([]AwayPeriod, error) {
+
+	var result []AwayPeriod
+
+	defer rows.Close()
+	for rows.Next() {
+		var a AwayPeriod
+
+		if err := rows.Scan(&a.ID, &a.MinionID, &a.DomainID, &a.DomainName, &a.StartsOn, &a.EndsOn); err != nil {
+			log.Printf("Error scanning away period: %q", err)
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+func AwayPeriodCreate(period AwayPeriod) error {
+
+	_, err := db.Exec("INSERT INTO away_periods (minion_id, domain_id, starts_on, ends_on) VALUES($1, $2, $3, $4)", period.MinionID, period.DomainID, util.StrDateFromTime(period.StartsOn), util.StrDateFromTime(period.EndsOn))
+	if err != nil {
+		log.Printf("Error inserting away period: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// AwayPeriodDelete removes one of the minion's away periods, returns sql.ErrNoRows if it isn't theirs
+func AwayPeriodDelete(minion Minion, periodID uint32) error {
+
+	result, err := db.Exec("DELETE FROM away_periods WHERE id = $1 AND minion_id = $2", periodID, minion.ID)
+	if err != nil {
+		log.Printf("Error deleting away period: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// MinionSetBackfill limits how many days back missed cards are still drawn, no limit when not valid
+func MinionSetBackfill(minion Minion, days sql.NullInt64) error {
+
+	_, err := db.Exec("UPDATE minions SET backfill_days = $1 WHERE id = $2", days, minion.ID)
+	if err != nil {
+		log.Printf("Error updating backfill: %q", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// GetBidsForMinion returns the bids the minion placed for the next auction of the domain
+func GetBidsForMinion(domain Domain, minion Minion) ([]Bid, error) {
+
+	rows, err := db.Query("SELECT domain_id, minion_id, task_id, points FROM domain_bids WHERE domain_id = $1 AND minion_id = $2", domain.ID, minion.ID)
+	if err != nil {
+		log.Printf("Error reading bids: %q", err)
+		return nil, err
+	}
+
+	return readBidsFromRows(rows)
+}
+
+// Bids returns all bids for the next auction
+func (dtx *DomainTx) Bids() ([]Bid, error) {
+
+	rows, err := dtx.tx.Query("SELECT domain_id, minion_id, task_id, points FROM domain_bids WHERE domain_id = $1", dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error reading bids: %q", err)
+		return nil, err
+	}
+
+	return readBidsFromRows(rows)
+}
+
+func readBidsFromRows(rows *sql.Rows) ([]Bid, error) {
+
+	var result []Bid
+
+	defer rows.Close()
+	for rows.Next() {
+		var b Bid
+
+		if err := rows.Scan(&b.DomainID, &b.MinionID, &b.TaskID, &b.Points); err != nil {
+			log.Printf("Error scanning bid: %q", err)
+			return nil, err
+		}
+		result = append(result, b)
+	}
+
+	return result, nil
+}
+
+// BidSet places or changes a bid, a bid of 0 points takes it back
+func (dtx *DomainTx) BidSet(bid Bid) error {
+
+	var err error
+	if bid.Points == 0 {
+		_, err = dtx.tx.Exec("DELETE FROM domain_bids WHERE domain_id = $1 AND minion_id = $2 AND task_id = $3", dtx.Domain.ID, bid.MinionID, bid.TaskID)
+	} else {
+		_, err = dtx.tx.Exec("INSERT INTO domain_bids (domain_id, minion_id, task_id, points) VALUES($1, $2, $3, $4) ON CONFLICT (domain_id, minion_id, task_id) DO UPDATE SET points = EXCLUDED.points", dtx.Domain.ID, bid.MinionID, bid.TaskID, bid.Points)
+	}
+	if err != nil {
+		log.Printf("Error saving bid: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+func (dtx *DomainTx) ClearBids() error {
+
+	_, err := dtx.tx.Exec("DELETE FROM domain_bids WHERE domain_id = $1", dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error clearing bids: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// SpendPoints takes points from the balance of the minion, which never goes below 0
+func (dtx *DomainTx) SpendPoints(minion Minion, points int) error {
+
+	_, err := dtx.tx.Exec("UPDATE minion_domain SET points = GREATEST(points - $1, 0) WHERE domain_id = $2 AND minion_id = $3", points, dtx.Domain.ID, minion.ID)
+	if err != nil {
+		log.Printf("Error spending points: %q", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+var ErrNotPending = errors.New("Not a pending assignment of this minion")
+
+// AssignmentsBulkUpdate changes the status of a number of pending assignments of the minion at once.
+// Either all of them change or none do. Done cards earn a point each, like they do one at a time, and
+// returned ones show up in the history of the domain.
+func AssignmentsBulkUpdate(minion Minion, changes map[uint32]AssignmentStatus) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, status := range changes {
+
+		assignment := TaskAssignment{ID: id, MinionID: sql.NullInt64{Int64: int64(minion.ID), Valid: true}, Status: status}
+
+		row := tx.QueryRow("UPDATE task_assignments SET previous_status = status, previous_snoozed_until = snoozed_until, status_changed_at = CURRENT_TIMESTAMP, status = $1 WHERE id = $2 AND minion_id = $3 AND status IN ('pending', 'snoozed') RETURNING task_id", status, id, minion.ID)
+		err = row.Scan(&assignment.Task.ID)
+		if err == sql.ErrNoRows {
+			return ErrNotPending
+		}
+		if err != nil {
+			log.Printf("Error updating assignment: %q", err)
+			return err
+		}
+
+		switch status {
+		case DoneAndAvailable, DoneAndStashed:
+			err = memberEarnPoints(tx, assignment, 1)
+		case Returned:
+			err = recordMove(tx, id, minion.ID, sql.NullInt64{}, MovedByReturn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
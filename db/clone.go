@@ -0,0 +1,53 @@
+package db
+
+import (
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// settings copied to a clone, everything that isn't state like the last reset or where the rotation is.
+// A clone starts out private, sharing it gets it a link of its own.
+const domainSettingColumns = "require_approval, assignment_mode, overdue_policy, overdue_days, timezone, week_start, weekend, reset_cadence, reset_day, carry_stash, exhaustion_policy, description, icon, colour, draws_per_day"
+
+// DomainClone creates a copy of the domain and all its tasks, owned by the minion. None of the cards that
+// were drawn come along. With members, everyone else in the domain joins the copy in the same role, apart
+// from the owner who becomes an admin. Returns the ID of the copy.
+func DomainClone(domain Domain, owner Minion, name string, withMembers bool) (uint32, error) {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var cloneID uint32
+	err = tx.QueryRow("INSERT INTO domains (owner, name, "+domainSettingColumns+") SELECT $1, $2, "+domainSettingColumns+" FROM domains WHERE id = $3 RETURNING id", owner.ID, name, domain.ID).Scan(&cloneID)
+	if err != nil {
+		log.Printf("Error cloning domain: %q", err)
+		return 0, err
+	}
+
+	_, err = tx.Exec("INSERT INTO tasks (domain_id, name, weekly, count, description, tags) SELECT $1, name, weekly, count, description, tags FROM tasks WHERE domain_id = $2 ORDER BY id", cloneID, domain.ID)
+	if err != nil {
+		log.Printf("Error cloning tasks: %q", err)
+		return 0, err
+	}
+
+	_, err = tx.Exec("INSERT INTO minion_domain (minion_id, domain_id, role) VALUES($1, $2, 'owner')", owner.ID, cloneID)
+	if err != nil {
+		log.Printf("Error inserting domain owner as member: %q", err)
+		return 0, err
+	}
+
+	if withMembers {
+		_, err = tx.Exec("INSERT INTO minion_domain (minion_id, domain_id, role) SELECT minion_id, $1, CASE WHEN role = 'owner' THEN 'admin' ELSE role END FROM minion_domain WHERE domain_id = $2 AND minion_id != $3", cloneID, domain.ID, owner.ID)
+		if err != nil {
+			log.Printf("Error cloning members: %q", err)
+			return 0, err
+		}
+	}
+
+	return cloneID, tx.Commit()
+}
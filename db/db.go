@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"time"
 
 	// have to import with an underbar alias since we need the init() to run
 	_ "github.com/lib/pq"
@@ -17,6 +18,27 @@ var (
 	db *sql.DB
 )
 
+// querier is what *sql.DB and *sql.Tx have in common, so the same query can run inside or outside a transaction
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanner is either *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// columns for scanDomain, to be used on a table aliased as 'd'
+const domainColumns = "d.id, d.owner, d.name, d.last_reset_date, d.require_approval, d.assignment_mode, d.rotation_position, d.rotation_dealt_until, d.overdue_policy, d.overdue_days, d.timezone, (SELECT o.timezone FROM minions o WHERE o.id = d.owner), d.week_start, d.weekend, (SELECT o.week_start FROM minions o WHERE o.id = d.owner), (SELECT o.weekend FROM minions o WHERE o.id = d.owner), d.reset_cadence, d.reset_day, d.carry_stash, d.exhaustion_policy, d.last_reshuffle_date, d.description, d.icon, d.colour, d.draws_per_day, d.visibility, d.share_token"
+
+// scanDomain reads domainColumns, followed by any extra columns
+func scanDomain(row scanner, d *Domain, extra ...interface{}) error {
+	dest := []interface{}{&d.ID, &d.Owner, &d.Name, &d.LastResetDate, &d.RequireApproval, &d.AssignmentMode, &d.RotationPosition, &d.RotationDealtUntil, &d.OverduePolicy, &d.OverdueDays, &d.Timezone, &d.OwnerTimezone, &d.WeekStart, &d.Weekend, &d.OwnerWeekStart, &d.OwnerWeekend, &d.ResetCadence, &d.ResetDay, &d.CarryStash, &d.ExhaustionPolicy, &d.LastReshuffleDate, &d.Description, &d.Icon, &d.Colour, &d.DrawsPerDay, &d.Visibility, &d.ShareToken}
+	return row.Scan(append(dest, extra...)...)
+}
+
 func init() {
 
 	config.ReadEnvironmentVars()
@@ -44,9 +66,9 @@ func CreateMinion(email, name string) error {
 
 func LoadMinion(email string, m *Minion) bool {
 
-	row := db.QueryRow("SELECT * FROM minions WHERE email = $1", email)
+	row := db.QueryRow("SELECT id, email, name, timezone, week_start, weekend, backfill_days, undo_minutes FROM minions WHERE email = $1", email)
 
-	err := row.Scan(&m.ID, &m.Email, &m.Name)
+	err := row.Scan(&m.ID, &m.Email, &m.Name, &m.Timezone, &m.WeekStart, &m.Weekend, &m.BackfillDays, &m.UndoMinutes)
 	if err != nil && err == sql.ErrNoRows {
 		return false
 	}
@@ -54,19 +76,47 @@ func LoadMinion(email string, m *Minion) bool {
 	return true
 }
 
-func CreateNewDomain(minion Minion, domainName string) error {
-	_, err := db.Exec("INSERT INTO domains (owner, name) VALUES($1, $2)", minion.ID, domainName)
+// CreateNewDomain creates a domain with the minion as owner and first member, and the tasks to start with.
+// Returns the ID of the new domain.
+func CreateNewDomain(minion Minion, domainName string, tasks []Task) (uint32, error) {
 
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var domainID uint32
+	err = tx.QueryRow("INSERT INTO domains (owner, name) VALUES($1, $2) RETURNING id", minion.ID, domainName).Scan(&domainID)
 	if err != nil {
 		log.Printf("Error inserting new domain: %q", err)
-		return err
+		return 0, err
 	}
 
-	return nil
+	_, err = tx.Exec("INSERT INTO minion_domain (minion_id, domain_id, role) VALUES($1, $2, 'owner')", minion.ID, domainID)
+	if err != nil {
+		log.Printf("Error inserting domain owner as member: %q", err)
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		task.DomainID = domainID
+		err = createNewTask(tx, task)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return domainID, tx.Commit()
 }
 
 func CreateNewTask(task Task) error {
-	_, err := db.Exec("INSERT INTO tasks (domain_id, name, weekly, count) VALUES($1, $2, $3, $4)", task.DomainID, task.Name, task.Weekly, task.Count)
+	return createNewTask(db, task)
+}
+
+func createNewTask(q querier, task Task) error {
+	_, err := q.Exec("INSERT INTO tasks (domain_id, name, weekly, count, description, tags) VALUES($1, $2, $3, $4, $5, $6)", task.DomainID, task.Name, task.Weekly, task.Count, task.Description, task.StoredTags())
 
 	if err != nil {
 		log.Printf("Error inserting new task: %q", err)
@@ -76,23 +126,46 @@ func CreateNewTask(task Task) error {
 	return nil
 }
 
-func GetDomainByID(domainID uint32) (Domain, error) {
-
-	row := db.QueryRow("SELECT id, owner, name, last_reset_date FROM domains WHERE id = $1", domainID)
+// GetSharedDomain finds the public domain a share link is for
+func GetSharedDomain(shareToken string) (Domain, error) {
 
 	var result Domain
 
-	err := row.Scan(&result.ID, &result.Owner, &result.Name, &result.LastResetDate)
-	if err == sql.ErrNoRows {
+	row := db.QueryRow("SELECT "+domainColumns+" FROM domains d WHERE d.share_token = $1 AND d.visibility = 'public'", shareToken)
+	err := scanDomain(row, &result)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error reading shared domain: %q", err)
+		}
 		return result, err
 	}
 
 	return result, nil
 }
 
+func GetDomainByID(domainID uint32) (Domain, error) {
+
+	return getDomainByID(db, domainID)
+}
+
+func getDomainByID(q querier, domainID uint32) (Domain, error) {
+
+	row := q.QueryRow("SELECT "+domainColumns+" FROM domains d WHERE d.id = $1", domainID)
+
+	var result Domain
+
+	err := scanDomain(row, &result)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error reading domain: %q", err)
+	}
+
+	return result, err
+}
+
+// GetDomainsForMinion returns all domains the minion owns or is a member of
 func GetDomainsForMinion(m Minion) []Domain {
 
-	rows, err := db.Query("SELECT d.id, d.owner, d.name, d.last_reset_date, COUNT(t.id) AS task_count FROM domains d LEFT JOIN tasks t ON d.id = t.domain_id WHERE owner = $1 GROUP BY d.id", m.ID)
+	rows, err := db.Query("SELECT "+domainColumns+", md.role, COUNT(t.id) AS task_count FROM domains d JOIN minion_domain md ON md.domain_id = d.id AND md.minion_id = $1 LEFT JOIN tasks t ON d.id = t.domain_id GROUP BY d.id, md.role ORDER BY d.id", m.ID)
 
 	if err != nil {
 		log.Printf("Error inquery: %q", err)
@@ -104,7 +177,7 @@ func GetDomainsForMinion(m Minion) []Domain {
 	for rows.Next() {
 		var d Domain
 
-		if err := rows.Scan(&d.ID, &d.Owner, &d.Name, &d.LastResetDate, &d.TaskCount); err != nil {
+		if err := scanDomain(rows, &d, &d.Role, &d.TaskCount); err != nil {
 			log.Printf("Error scanning domains: %q", err)
 			return nil
 		}
@@ -123,9 +196,185 @@ func DomainDelete(domain Domain) {
 	}
 }
 
+// DomainSetAssignmentMode switches how cards are handed out. Rotation starts fresh from today
+// so switching back and forth doesn't deal cards for all the days in between, and any cards
+// claimed so far are released so a draft starts from a full deck.
+func DomainSetAssignmentMode(domain Domain, mode AssignmentMode) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE domains SET assignment_mode = $1, rotation_dealt_until = NULL WHERE id = $2", mode, domain.ID)
+	if err != nil {
+		log.Printf("Error updating assignment mode: %q", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM domain_allocations WHERE domain_id = $1", domain.ID)
+	if err != nil {
+		log.Printf("Error resetting allocations: %q", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DomainSetOverduePolicy sets what happens to cards that are overdue for a number of days
+func DomainSetOverduePolicy(domain Domain, policy OverduePolicy, days uint32) error {
+
+	_, err := db.Exec("UPDATE domains SET overdue_policy = $1, overdue_days = $2 WHERE id = $3", policy, days, domain.ID)
+	if err != nil {
+		log.Printf("Error updating overdue policy: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainSetResetCadence sets how often the deck is reset, the day of the month only matters for monthly resets
+func DomainSetResetCadence(domain Domain, cadence ResetCadence, day uint32, carryStash bool) error {
+
+	_, err := db.Exec("UPDATE domains SET reset_cadence = $1, reset_day = $2, carry_stash = $3 WHERE id = $4", cadence, day, carryStash, domain.ID)
+	if err != nil {
+		log.Printf("Error updating reset cadence: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainSetSettings saves the name, description, icon, colour and draws per day of the domain
+func DomainSetSettings(domain Domain) error {
+
+	_, err := db.Exec("UPDATE domains SET name = $1, description = $2, icon = $3, colour = $4, draws_per_day = $5 WHERE id = $6", domain.Name, domain.Description, domain.Icon, domain.Colour, domain.DrawsPerDay, domain.ID)
+	if err != nil {
+		log.Printf("Error updating domain settings: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainSetVisibility sets who can look at the domain. A public domain needs a share token for its link,
+// a private one has none so old links stop working.
+func DomainSetVisibility(domain Domain, visibility Visibility, shareToken sql.NullString) error {
+
+	_, err := db.Exec("UPDATE domains SET visibility = $1, share_token = $2 WHERE id = $3", visibility, shareToken, domain.ID)
+	if err != nil {
+		log.Printf("Error updating visibility: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+func DomainSetExhaustionPolicy(domain Domain, policy ExhaustionPolicy) error {
+
+	_, err := db.Exec("UPDATE domains SET exhaustion_policy = $1 WHERE id = $2", policy, domain.ID)
+	if err != nil {
+		log.Printf("Error updating exhaustion policy: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDomainsWithOverduePolicy returns the domains that do something with overdue cards
+func GetDomainsWithOverduePolicy() ([]Domain, error) {
+
+	rows, err := db.Query("SELECT " + domainColumns + " FROM domains d WHERE d.overdue_policy != 'keep' ORDER BY d.id")
+	if err != nil {
+		log.Printf("Error reading domains: %q", err)
+		return nil, err
+	}
+
+	var result []Domain
+	defer rows.Close()
+	for rows.Next() {
+		var d Domain
+
+		if err := scanDomain(rows, &d); err != nil {
+			log.Printf("Error scanning domains: %q", err)
+			return nil, err
+		}
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+func MinionSetTimezone(minion Minion, timezone string) error {
+
+	_, err := db.Exec("UPDATE minions SET timezone = $1 WHERE id = $2", timezone, minion.ID)
+	if err != nil {
+		log.Printf("Error updating timezone: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainSetTimezone gives the domain its own timezone, or makes it follow the owner's when not valid
+func DomainSetTimezone(domain Domain, timezone sql.NullString) error {
+
+	_, err := db.Exec("UPDATE domains SET timezone = $1 WHERE id = $2", timezone, domain.ID)
+	if err != nil {
+		log.Printf("Error updating timezone: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// MinionSetCalendar sets the day the minion's weeks start on and their weekend days
+func MinionSetCalendar(minion Minion, calendar util.Calendar) error {
+
+	_, err := db.Exec("UPDATE minions SET week_start = $1, weekend = $2 WHERE id = $3", calendar.WeekStart, calendar.Weekend, minion.ID)
+	if err != nil {
+		log.Printf("Error updating calendar: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// MinionSetUndoWindow sets how many minutes finishing a card can be taken back, until the end of the day when not valid
+func MinionSetUndoWindow(minion Minion, minutes sql.NullInt64) error {
+
+	_, err := db.Exec("UPDATE minions SET undo_minutes = $1 WHERE id = $2", minutes, minion.ID)
+	if err != nil {
+		log.Printf("Error updating undo window: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainSetCalendar gives the domain its own calendar, or makes it follow the owner's when nil
+func DomainSetCalendar(domain Domain, calendar *util.Calendar) error {
+
+	var weekStart, weekend sql.NullInt64
+	if calendar != nil {
+		weekStart = sql.NullInt64{Int64: int64(calendar.WeekStart), Valid: true}
+		weekend = sql.NullInt64{Int64: int64(calendar.Weekend), Valid: true}
+	}
+
+	_, err := db.Exec("UPDATE domains SET week_start = $1, weekend = $2 WHERE id = $3", weekStart, weekend, domain.ID)
+	if err != nil {
+		log.Printf("Error updating calendar: %q", err)
+		return err
+	}
+
+	return nil
+}
+
 func ReadAllMinions() ([]Minion, error) {
 
-	rows, err := db.Query("SELECT * FROM minions")
+	rows, err := db.Query("SELECT id, email, name, timezone, week_start, weekend, backfill_days, undo_minutes FROM minions")
 	if err != nil {
 		log.Printf("Error reading minions: %q", err)
 		return nil, err
@@ -137,7 +386,7 @@ func ReadAllMinions() ([]Minion, error) {
 	for rows.Next() {
 		var m Minion
 
-		if err := rows.Scan(&m.ID, &m.Email, &m.Name); err != nil {
+		if err := rows.Scan(&m.ID, &m.Email, &m.Name, &m.Timezone, &m.WeekStart, &m.Weekend, &m.BackfillDays, &m.UndoMinutes); err != nil {
 			log.Printf("Error scanning minion: %q", err)
 			return nil, err
 		}
@@ -148,13 +397,63 @@ func ReadAllMinions() ([]Minion, error) {
 }
 
 func ResetAllCompletedTasks(domain Domain) error {
+	return resetAllCompletedTasks(db, domain, time.Now().In(domain.Location()))
+}
+
+func resetAllCompletedTasks(q querier, domain Domain, today time.Time) error {
 
-	_, err := db.Exec("DELETE FROM task_assignments WHERE status != 'pending' AND domain_id = $1", domain.ID)
+	err := shuffleBack(q, domain, today, !domain.CarryStash)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec("UPDATE domains SET last_reset_date = CURRENT_DATE WHERE id = $1", domain.ID)
+
+	_, err = q.Exec("UPDATE domains SET last_reset_date = $2 WHERE id = $1", domain.ID, util.StrDateFromTime(today))
 	if err != nil {
+		log.Printf("Error updating reset date: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// reshuffle puts the done cards back in the deck when it ran out before the next reset
+func reshuffle(q querier, domain Domain, today time.Time, withStash bool) error {
+
+	err := shuffleBack(q, domain, today, withStash)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec("UPDATE domains SET last_reshuffle_date = $2 WHERE id = $1", domain.ID, util.StrDateFromTime(today))
+	if err != nil {
+		log.Printf("Error updating reshuffle date: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// shuffleBack forgets all completed cards, and the stashed ones too if asked, so they can be drawn again
+func shuffleBack(q querier, domain Domain, today time.Time, withStash bool) error {
+
+	kept := "'pending', 'snoozed', 'done_and_stashed'"
+	if withStash {
+		kept = "'pending', 'snoozed'"
+	}
+	_, err := q.Exec("DELETE FROM task_assignments WHERE status NOT IN ("+kept+") AND task_id IN (SELECT id FROM tasks WHERE domain_id = $1)", domain.ID)
+	if err != nil {
+		log.Printf("Error resetting assignments: %q", err)
+		return err
+	}
+	_, err = q.Exec("DELETE FROM assignment_offers WHERE domain_id = $1 AND expires_on < $2", domain.ID, util.StrDateFromTime(today))
+	if err != nil {
+		log.Printf("Error removing expired offers: %q", err)
+		return err
+	}
+	// claims only last for one period, in draft mode this starts a new draft
+	_, err = q.Exec("DELETE FROM domain_allocations WHERE domain_id = $1", domain.ID)
+	if err != nil {
+		log.Printf("Error resetting allocations: %q", err)
 		return err
 	}
 
@@ -162,10 +461,14 @@ func ResetAllCompletedTasks(domain Domain) error {
 }
 
 func GetAvailableTasksForDomain(domain Domain) ([]Task, error) {
+	return getAvailableTasksForDomain(db, domain)
+}
+
+func getAvailableTasksForDomain(q querier, domain Domain) ([]Task, error) {
 
 	var result []Task
 
-	rows, err := db.Query("SELECT t.id, t.domain_id, t.name, t.weekly, t.description, CASE WHEN ta.used IS NULL THEN t.count ELSE t.count - ta.used END AS available FROM tasks t LEFT JOIN (SELECT task_id, COUNT(*) AS used FROM task_assignments WHERE status != 'done_and_available' GROUP BY task_id) ta ON ta.task_id = t.id WHERE domain_id = $1", domain.ID)
+	rows, err := q.Query("SELECT t.id, t.domain_id, t.name, t.weekly, t.description, t.tags, CASE WHEN ta.used IS NULL THEN t.count ELSE t.count - ta.used END AS available FROM tasks t LEFT JOIN (SELECT task_id, COUNT(*) AS used FROM task_assignments WHERE status NOT IN ('done_and_available', 'returned', 'skipped') GROUP BY task_id) ta ON ta.task_id = t.id WHERE domain_id = $1", domain.ID)
 	if err != nil {
 		log.Printf("Error reading tasks: %q\n", err)
 		return result, err
@@ -177,7 +480,7 @@ func GetAvailableTasksForDomain(domain Domain) ([]Task, error) {
 		// results of math ops in postgres end up as int64 columns
 		var taskCount int64
 
-		if err := rows.Scan(&t.ID, &t.DomainID, &t.Name, &t.Weekly, &t.Description, &taskCount); err != nil {
+		if err := rows.Scan(&t.ID, &t.DomainID, &t.Name, &t.Weekly, &t.Description, &t.Tags, &taskCount); err != nil {
 			log.Printf("Error scanning task: %q", err)
 			return result, err
 		}
@@ -199,7 +502,7 @@ func readTasksFromRows(rows *sql.Rows) ([]Task, error) {
 	for rows.Next() {
 		var t Task
 
-		if err := rows.Scan(&t.ID, &t.DomainID, &t.Name, &t.Weekly, &t.Description, &t.Count); err != nil {
+		if err := rows.Scan(&t.ID, &t.DomainID, &t.Name, &t.Weekly, &t.Description, &t.Tags, &t.Count); err != nil {
 			log.Printf("Error scanning task: %q", err)
 			return result, err
 		}
@@ -209,8 +512,12 @@ func readTasksFromRows(rows *sql.Rows) ([]Task, error) {
 }
 
 func GetTasksForDomain(domain Domain) ([]Task, error) {
+	return getTasksForDomain(db, domain)
+}
 
-	rows, err := db.Query("SELECT id, domain_id, name, weekly, description, count FROM tasks WHERE domain_id = $1", domain.ID)
+func getTasksForDomain(q querier, domain Domain) ([]Task, error) {
+
+	rows, err := q.Query("SELECT id, domain_id, name, weekly, description, tags, count FROM tasks WHERE domain_id = $1", domain.ID)
 	if err != nil {
 		log.Printf("Error reading tasks for domain: %q", err)
 		return nil, err
@@ -221,9 +528,13 @@ func GetTasksForDomain(domain Domain) ([]Task, error) {
 }
 
 func AssignmentInsert(assignment TaskAssignment) error {
+	return assignmentInsert(db, assignment)
+}
+
+func assignmentInsert(q querier, assignment TaskAssignment) error {
 
 	strDate := util.StrDateFromTime(assignment.AssignedDate.Time)
-	_, err := db.Exec("INSERT INTO task_assignments (task_id, minion_id, assigned_on) VALUES($1,$2,$3)", assignment.Task.ID, assignment.MinionID, strDate)
+	_, err := q.Exec("INSERT INTO task_assignments (task_id, minion_id, drawn_by, assigned_on) VALUES($1,$2,$2,$3)", assignment.Task.ID, assignment.MinionID, strDate)
 
 	if err != nil {
 		log.Printf("Error inserting new minion: %q", err)
@@ -236,7 +547,7 @@ func AssignmentUpdate(assignment TaskAssignment) error {
 
 	strDateAssigned := util.StrDateFromTime(assignment.AssignedDate.Time)
 
-	_, err := db.Exec("UPDATE task_assignments SET assigned_on = $1, status = $2 WHERE id = $3", strDateAssigned, assignment.Status, assignment.ID)
+	_, err := db.Exec("UPDATE task_assignments SET previous_status = status, previous_snoozed_until = snoozed_until, status_changed_at = CURRENT_TIMESTAMP, assigned_on = $1, status = $2, snoozed_until = $3 WHERE id = $4", strDateAssigned, assignment.Status, assignment.SnoozedUntil, assignment.ID)
 
 	if err != nil {
 		log.Printf("Error updating assignment: %q", err)
@@ -245,6 +556,22 @@ func AssignmentUpdate(assignment TaskAssignment) error {
 	return nil
 }
 
+// AssignmentTransition saves the new status of an assignment, unless someone changed it after it was read.
+// Returns sql.ErrNoRows when it isn't in the from status anymore.
+func assignmentTransition(q querier, assignment TaskAssignment, from AssignmentStatus) error {
+
+	result, err := q.Exec("UPDATE task_assignments SET previous_status = status, previous_snoozed_until = snoozed_until, status_changed_at = CURRENT_TIMESTAMP, status = $1, snoozed_until = $2 WHERE id = $3 AND status = $4", assignment.Status, assignment.SnoozedUntil, assignment.ID, from)
+	if err != nil {
+		log.Printf("Error updating assignment: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func AssignmentDelete(assignment TaskAssignment) error {
 
 	_, err := db.Exec("DELETE FROM task_assignments WHERE id = $1", assignment.ID)
@@ -256,17 +583,19 @@ func AssignmentDelete(assignment TaskAssignment) error {
 	return nil
 }
 
-func AssignmentRetrieve(taskAssignmentID int64) *TaskAssignment {
+// AssignmentRetrieve reads one assignment, with its age as seen on today. Cards drawn in a zone ahead of today
+// are 0 days old, not negative.
+func AssignmentRetrieve(taskAssignmentID int64, today time.Time) *TaskAssignment {
 
 	var result TaskAssignment
 
-	row := db.QueryRow("SELECT id, task_id, assigned_on, status, CURRENT_DATE - assigned_on AS days_old FROM task_assignments WHERE id = $1", taskAssignmentID)
+	row := db.QueryRow("SELECT ta.id, ta.task_id, t.domain_id, ta.minion_id, ta.assigned_on, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, GREATEST(0, $2::date - ta.assigned_on) AS days_old FROM task_assignments AS ta JOIN tasks AS t ON ta.task_id = t.id WHERE ta.id = $1", taskAssignmentID, util.StrDateFromTime(today))
 	log.Printf("row: %v\n", row)
 	if row == nil {
 		log.Println("rowNIL")
 	}
 
-	err := row.Scan(&result.ID, &result.Task.ID, &result.AssignedDate, &result.Status, &result.AgeInDays)
+	err := row.Scan(&result.ID, &result.Task.ID, &result.Task.DomainID, &result.MinionID, &result.AssignedDate, &result.Status, &result.SnoozedUntil, &result.PreviousStatus, &result.PreviousSnoozedUntil, &result.StatusChangedAt, &result.AgeInDays)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("No task assignment with ID: %d", taskAssignmentID)
@@ -282,29 +611,39 @@ func AssignmentRetrieve(taskAssignmentID int64) *TaskAssignment {
 // Retrieve all pending tasks for a minion, across all domains
 func AssignmentRetrieveForMinion(minion Minion, includeCompleted bool) []TaskAssignment {
 
-	var result []TaskAssignment
-
-	sql := "SELECT ta.id, task_id, assigned_on, CURRENT_DATE - assigned_on AS days_old, ta.status, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta LEFT JOIN tasks AS t ON ta.task_id = t.id WHERE status = 'pending' AND ta.minion_id = $1"
+	sql := "SELECT ta.id, task_id, ta.minion_id, assigned_on, GREATEST(0, $2::date - assigned_on) AS days_old, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta LEFT JOIN tasks AS t ON ta.task_id = t.id WHERE status IN ('pending', 'snoozed') AND ta.minion_id = $1"
 	if includeCompleted {
-		sql = "SELECT ta.id, task_id, assigned_on, CURRENT_DATE - assigned_on AS days_old, ta.status, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta LEFT JOIN tasks AS t ON ta.task_id = t.id WHERE ta.minion_id = $1"
+		sql = "SELECT ta.id, task_id, ta.minion_id, assigned_on, GREATEST(0, $2::date - assigned_on) AS days_old, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta LEFT JOIN tasks AS t ON ta.task_id = t.id WHERE ta.minion_id = $1"
 	}
 
-	rows, err := db.Query(sql, minion.ID)
+	// age in days as seen from the minion's timezone
+	today := util.StrDateFromTime(time.Now().In(minion.Location()))
+	rows, err := db.Query(sql, minion.ID, today)
 	if err != nil {
 		log.Printf("Error reading pending tasks: %q", err)
 		return nil
 	}
 
+	result, err := readAssignmentsFromRows(rows)
+	if err != nil {
+		return nil
+	}
+
+	return result
+}
+
+func readAssignmentsFromRows(rows *sql.Rows) ([]TaskAssignment, error) {
+	var result []TaskAssignment
+
 	defer rows.Close()
 	for rows.Next() {
 		var ta TaskAssignment
 
-		if err := rows.Scan(&ta.ID, &ta.Task.ID, &ta.AssignedDate, &ta.AgeInDays, &ta.Status, &ta.Task.DomainID, &ta.Task.Name, &ta.Task.Weekly, &ta.Task.Description); err != nil {
+		if err := rows.Scan(&ta.ID, &ta.Task.ID, &ta.MinionID, &ta.AssignedDate, &ta.AgeInDays, &ta.Status, &ta.SnoozedUntil, &ta.PreviousStatus, &ta.PreviousSnoozedUntil, &ta.StatusChangedAt, &ta.Task.DomainID, &ta.Task.Name, &ta.Task.Weekly, &ta.Task.Description); err != nil {
 			log.Printf("Error scanning task: %q", err)
-			return nil
+			return result, err
 		}
 		result = append(result, ta)
 	}
-
-	return result
+	return result, nil
 }
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+// first key for pg_advisory_xact_lock(int, int), the second one is the domain ID
+const drawLockKey = 1
+
+// DomainTx is a transaction holding the draw lock of a single Domain. Everything that looks at
+// what is left in the deck and then draws from it should go through here, otherwise two members
+// loading the page at the same time can both draw the last copy of a card.
+type DomainTx struct {
+	tx     *sql.Tx
+	Domain Domain
+}
+
+// WithDomainLock runs f inside a transaction that holds the draw lock for the domain.
+// The lock is released when the transaction commits, or rolls back when f returns an error.
+func WithDomainLock(domain Domain, f func(dtx *DomainTx) error) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", drawLockKey, domain.ID)
+	if err != nil {
+		log.Printf("Error locking domain %d: %q", domain.ID, err)
+		return err
+	}
+
+	// someone else might have reset the domain or dealt cards while we were waiting
+	role := domain.Role
+	domain, err = getDomainByID(tx, domain.ID)
+	if err != nil {
+		return err
+	}
+	domain.Role = role
+
+	err = f(&DomainTx{tx: tx, Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (dtx *DomainTx) Tasks() ([]Task, error) {
+	return getTasksForDomain(dtx.tx, dtx.Domain)
+}
+
+func (dtx *DomainTx) AvailableTasks() ([]Task, error) {
+	return getAvailableTasksForDomain(dtx.tx, dtx.Domain)
+}
+
+// AssignmentsForMinion returns everything the minion has drawn from this domain since the last reset,
+// including cards they handed to someone else
+func (dtx *DomainTx) AssignmentsForMinion(minion Minion, today time.Time) ([]TaskAssignment, error) {
+
+	rows, err := dtx.tx.Query("SELECT ta.id, task_id, ta.minion_id, assigned_on, GREATEST(0, $3::date - assigned_on) AS days_old, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta JOIN tasks AS t ON ta.task_id = t.id WHERE ta.drawn_by = $1 AND t.domain_id = $2", minion.ID, dtx.Domain.ID, util.StrDateFromTime(today))
+	if err != nil {
+		log.Printf("Error reading assignments: %q", err)
+		return nil, err
+	}
+
+	return readAssignmentsFromRows(rows)
+}
+
+func (dtx *DomainTx) Members() ([]DomainMember, error) {
+	return getMembersForDomain(dtx.tx, dtx.Domain)
+}
+
+func (dtx *DomainTx) AssignmentInsert(assignment TaskAssignment) error {
+	return assignmentInsert(dtx.tx, assignment)
+}
+
+func (dtx *DomainTx) AssignmentTransition(assignment TaskAssignment, from AssignmentStatus) error {
+	return assignmentTransition(dtx.tx, assignment, from)
+}
+
+// AssignmentUndo takes back the last status change of the assignment, unless something else changed
+// it since. Taking back a done card takes back its point as well.
+func (dtx *DomainTx) AssignmentUndo(assignment TaskAssignment) error {
+
+	result, err := dtx.tx.Exec("UPDATE task_assignments SET status = previous_status, snoozed_until = previous_snoozed_until, previous_status = NULL, previous_snoozed_until = NULL, status_changed_at = NULL WHERE id = $1 AND status = $2 AND previous_status IS NOT NULL", assignment.ID, assignment.Status)
+	if err != nil {
+		log.Printf("Error undoing assignment: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	if assignment.Status.IsDone() {
+		return memberEarnPoints(dtx.tx, assignment, -1)
+	}
+
+	return nil
+}
+
+func (dtx *DomainTx) ResetAllCompletedTasks(today time.Time) error {
+	return resetAllCompletedTasks(dtx.tx, dtx.Domain, today)
+}
+
+// Reshuffle puts the done cards back in the deck without moving the date of the next reset
+func (dtx *DomainTx) Reshuffle(today time.Time, withStash bool) error {
+	return reshuffle(dtx.tx, dtx.Domain, today, withStash)
+}
+
+// SetRotation saves where the wheel stopped and the last date cards were dealt for
+func (dtx *DomainTx) SetRotation(position uint32, dealtUntil time.Time) error {
+
+	_, err := dtx.tx.Exec("UPDATE domains SET rotation_position = $1, rotation_dealt_until = $2 WHERE id = $3", position, util.StrDateFromTime(dealtUntil), dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error saving rotation: %q", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// ErrInviteInvalid is returned when an invite doesn't exist, expired or has been used up
+var ErrInviteInvalid = errors.New("Invite is not valid anymore")
+
+func InviteCreate(invite DomainInvite) error {
+
+	_, err := db.Exec("INSERT INTO domain_invites (domain_id, token, code, created_by, expires_on, uses_left) VALUES($1, $2, $3, $4, $5, $6)", invite.DomainID, invite.Token, invite.Code, invite.CreatedBy, invite.ExpiresOn, invite.UsesLeft)
+	if err != nil {
+		log.Printf("Error inserting invite: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetInvitesForDomain returns the invites that can still be used
+func GetInvitesForDomain(domain Domain) ([]DomainInvite, error) {
+
+	rows, err := db.Query("SELECT id, domain_id, token, code, created_by, created_on, expires_on, uses_left FROM domain_invites WHERE domain_id = $1 AND uses_left > 0 AND expires_on > CURRENT_TIMESTAMP ORDER BY created_on", domain.ID)
+	if err != nil {
+		log.Printf("Error reading invites: %q", err)
+		return nil, err
+	}
+
+	var result []DomainInvite
+
+	defer rows.Close()
+	for rows.Next() {
+		var i DomainInvite
+
+		if err := rows.Scan(&i.ID, &i.DomainID, &i.Token, &i.Code, &i.CreatedBy, &i.CreatedOn, &i.ExpiresOn, &i.UsesLeft); err != nil {
+			log.Printf("Error scanning invite: %q", err)
+			return nil, err
+		}
+		result = append(result, i)
+	}
+
+	return result, nil
+}
+
+func InviteRevoke(domain Domain, inviteID uint32) error {
+
+	_, err := db.Exec("DELETE FROM domain_invites WHERE id = $1 AND domain_id = $2", inviteID, domain.ID)
+	if err != nil {
+		log.Printf("Error revoking invite: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// InviteRedeem uses up an invite (found by token or by code) for the minion.
+// Depending on the domain this either adds the minion as a member or files a join request.
+// Returns the domain and whether the minion is waiting for approval.
+func InviteRedeem(tokenOrCode string, minion Minion) (Domain, bool, error) {
+
+	var domain Domain
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return domain, false, err
+	}
+	defer tx.Rollback()
+
+	var invite DomainInvite
+	row := tx.QueryRow("SELECT id, domain_id, expires_on, uses_left FROM domain_invites WHERE token = $1 OR code = UPPER($1) FOR UPDATE", tokenOrCode)
+	err = row.Scan(&invite.ID, &invite.DomainID, &invite.ExpiresOn, &invite.UsesLeft)
+	if err == sql.ErrNoRows {
+		return domain, false, ErrInviteInvalid
+	}
+	if err != nil {
+		log.Printf("Error reading invite: %q", err)
+		return domain, false, err
+	}
+	if !invite.IsValid(time.Now()) {
+		return domain, false, ErrInviteInvalid
+	}
+
+	domain, err = getDomainByID(tx, invite.DomainID)
+	if err != nil {
+		log.Printf("Error reading domain for invite: %q", err)
+		return domain, false, err
+	}
+
+	// following the link again shouldn't use it up
+	var isMember, isWaiting bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM minion_domain WHERE domain_id = $1 AND minion_id = $2), EXISTS(SELECT 1 FROM join_requests WHERE domain_id = $1 AND minion_id = $2)", domain.ID, minion.ID).Scan(&isMember, &isWaiting)
+	if err != nil {
+		log.Printf("Error checking membership: %q", err)
+		return domain, false, err
+	}
+	if isMember || isWaiting {
+		return domain, isWaiting, nil
+	}
+
+	if domain.RequireApproval {
+		_, err = tx.Exec("INSERT INTO join_requests (minion_id, domain_id) VALUES($1, $2) ON CONFLICT DO NOTHING", minion.ID, domain.ID)
+	} else {
+		_, err = tx.Exec("INSERT INTO minion_domain (minion_id, domain_id) VALUES($1, $2)", minion.ID, domain.ID)
+	}
+	if err != nil {
+		log.Printf("Error joining domain: %q", err)
+		return domain, false, err
+	}
+
+	_, err = tx.Exec("UPDATE domain_invites SET uses_left = uses_left - 1 WHERE id = $1", invite.ID)
+	if err != nil {
+		log.Printf("Error using invite: %q", err)
+		return domain, false, err
+	}
+
+	return domain, domain.RequireApproval, tx.Commit()
+}
+
+func GetJoinRequestsForDomain(domain Domain) ([]JoinRequest, error) {
+
+	rows, err := db.Query("SELECT m.id, m.email, m.name, jr.domain_id, jr.requested_on FROM join_requests jr JOIN minions m ON m.id = jr.minion_id WHERE jr.domain_id = $1 ORDER BY jr.requested_on", domain.ID)
+	if err != nil {
+		log.Printf("Error reading join requests: %q", err)
+		return nil, err
+	}
+
+	var result []JoinRequest
+
+	defer rows.Close()
+	for rows.Next() {
+		var jr JoinRequest
+
+		if err := rows.Scan(&jr.Minion.ID, &jr.Minion.Email, &jr.Minion.Name, &jr.DomainID, &jr.RequestedOn); err != nil {
+			log.Printf("Error scanning join request: %q", err)
+			return nil, err
+		}
+		result = append(result, jr)
+	}
+
+	return result, nil
+}
+
+// JoinRequestResolve removes the join request, and when approved adds the minion as a member
+func JoinRequestResolve(domain Domain, minion Minion, approve bool) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM join_requests WHERE minion_id = $1 AND domain_id = $2", minion.ID, domain.ID)
+	if err != nil {
+		log.Printf("Error deleting join request: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	if approve {
+		_, err = tx.Exec("INSERT INTO minion_domain (minion_id, domain_id) VALUES($1, $2) ON CONFLICT DO NOTHING", minion.ID, domain.ID)
+		if err != nil {
+			log.Printf("Error adding member: %q", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func DomainSetRequireApproval(domain Domain, requireApproval bool) error {
+
+	_, err := db.Exec("UPDATE domains SET require_approval = $1 WHERE id = $2", requireApproval, domain.ID)
+	if err != nil {
+		log.Printf("Error updating domain: %q", err)
+		return err
+	}
+
+	return nil
+}
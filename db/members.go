@@ -0,0 +1,220 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// GetMembersForDomain returns everyone who shares the domain, in the order they joined
+func GetMembersForDomain(domain Domain) ([]DomainMember, error) {
+	return getMembersForDomain(db, domain)
+}
+
+func getMembersForDomain(q querier, domain Domain) ([]DomainMember, error) {
+
+	rows, err := q.Query("SELECT m.id, m.email, m.name, md.role, md.points FROM minion_domain md JOIN minions m ON m.id = md.minion_id WHERE md.domain_id = $1 ORDER BY md.joined_on, m.id", domain.ID)
+	if err != nil {
+		log.Printf("Error reading members: %q", err)
+		return nil, err
+	}
+
+	var result []DomainMember
+
+	defer rows.Close()
+	for rows.Next() {
+		var m DomainMember
+
+		if err := rows.Scan(&m.ID, &m.Email, &m.Name, &m.Role, &m.Points); err != nil {
+			log.Printf("Error scanning member: %q", err)
+			return nil, err
+		}
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// GetMembership returns the role the minion has in the domain, or false if they are not a member
+func GetMembership(domain Domain, minion Minion) (Role, bool) {
+
+	var role Role
+	row := db.QueryRow("SELECT role FROM minion_domain WHERE domain_id = $1 AND minion_id = $2", domain.ID, minion.ID)
+	err := row.Scan(&role)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error checking membership: %q", err)
+		}
+		return role, false
+	}
+
+	return role, true
+}
+
+// GetPoints returns the points balance of the minion in the domain
+func GetPoints(domain Domain, minion Minion) (int, error) {
+
+	var points int
+	row := db.QueryRow("SELECT points FROM minion_domain WHERE domain_id = $1 AND minion_id = $2", domain.ID, minion.ID)
+	err := row.Scan(&points)
+	if err != nil {
+		log.Printf("Error reading points: %q", err)
+		return 0, err
+	}
+
+	return points, nil
+}
+
+// MemberEarnPoints adds points to the balance of whoever the assignment was for, in the domain of its task
+// MemberEarnPoints gives whoever has the card points, or takes them away when negative
+func (dtx *DomainTx) MemberEarnPoints(assignment TaskAssignment, points int) error {
+	return memberEarnPoints(dtx.tx, assignment, points)
+}
+
+func memberEarnPoints(q querier, assignment TaskAssignment, points int) error {
+
+	_, err := q.Exec("UPDATE minion_domain SET points = points + $1 WHERE minion_id = $2 AND domain_id = (SELECT domain_id FROM tasks WHERE id = $3)", points, assignment.MinionID, assignment.Task.ID)
+	if err != nil {
+		log.Printf("Error adding points: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+func DomainAddMember(domain Domain, minion Minion, role Role) error {
+
+	_, err := db.Exec("INSERT INTO minion_domain (minion_id, domain_id, role) VALUES($1, $2, $3) ON CONFLICT DO NOTHING", minion.ID, domain.ID, role)
+	if err != nil {
+		log.Printf("Error adding member: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+func DomainSetMemberRole(domain Domain, minion Minion, role Role) error {
+
+	_, err := db.Exec("UPDATE minion_domain SET role = $1 WHERE domain_id = $2 AND minion_id = $3", role, domain.ID, minion.ID)
+	if err != nil {
+		log.Printf("Error updating member role: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// DomainTransferOwnership makes another member the owner, the old owner stays on as admin
+func DomainTransferOwnership(domain Domain, newOwner Minion) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE minion_domain SET role = 'owner' WHERE domain_id = $1 AND minion_id = $2", domain.ID, newOwner.ID)
+	if err != nil {
+		log.Printf("Error updating new owner: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = setOwner(tx, domain, newOwner.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DomainRemoveMember removes the minion from the domain. Any cards they still have pending
+// or claimed go back into the deck so the others can draw them.
+// When the owner leaves the highest ranking member that joined first takes over, and when
+// nobody is left the domain is deleted.
+func DomainRemoveMember(domain Domain, minion Minion) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM task_assignments WHERE minion_id = $1 AND status IN ('pending', 'snoozed') AND task_id IN (SELECT id FROM tasks WHERE domain_id = $2)", minion.ID, domain.ID)
+	if err != nil {
+		log.Printf("Error returning pending assignments: %q", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM domain_allocations WHERE minion_id = $1 AND domain_id = $2", minion.ID, domain.ID)
+	if err != nil {
+		log.Printf("Error returning allocated cards: %q", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM domain_bids WHERE minion_id = $1 AND domain_id = $2", minion.ID, domain.ID)
+	if err != nil {
+		log.Printf("Error removing bids: %q", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM minion_domain WHERE minion_id = $1 AND domain_id = $2", minion.ID, domain.ID)
+	if err != nil {
+		log.Printf("Error removing member: %q", err)
+		return err
+	}
+
+	if domain.Owner == minion.ID {
+
+		var successorID uint32
+		row := tx.QueryRow("SELECT minion_id FROM minion_domain WHERE domain_id = $1 ORDER BY role, joined_on, minion_id LIMIT 1", domain.ID)
+		err = row.Scan(&successorID)
+
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec("DELETE FROM domains WHERE id = $1", domain.ID)
+			if err != nil {
+				log.Printf("Error deleting abandoned domain: %q", err)
+				return err
+			}
+		case err != nil:
+			log.Printf("Error finding new owner: %q", err)
+			return err
+		default:
+			_, err = tx.Exec("UPDATE minion_domain SET role = 'owner' WHERE domain_id = $1 AND minion_id = $2", domain.ID, successorID)
+			if err != nil {
+				log.Printf("Error updating new owner: %q", err)
+				return err
+			}
+			err = setOwner(tx, domain, successorID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// setOwner points the domain at its new owner, demoting the previous one to admin if they are still around
+func setOwner(tx *sql.Tx, domain Domain, ownerID uint32) error {
+
+	_, err := tx.Exec("UPDATE minion_domain SET role = 'admin' WHERE domain_id = $1 AND role = 'owner' AND minion_id != $2", domain.ID, ownerID)
+	if err != nil {
+		log.Printf("Error demoting previous owner: %q", err)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE domains SET owner = $1 WHERE id = $2", ownerID, domain.ID)
+	if err != nil {
+		log.Printf("Error updating domain owner: %q", err)
+		return err
+	}
+
+	return nil
+}
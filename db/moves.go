@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+func recordMove(q querier, assignmentID uint32, from uint32, to sql.NullInt64, reason MoveReason) error {
+
+	_, err := q.Exec("INSERT INTO assignment_moves (assignment_id, from_minion, to_minion, reason) VALUES($1, $2, $3, $4)", assignmentID, from, to, reason)
+	if err != nil {
+		log.Printf("Error recording move: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetMovesForDomain returns the most recent cards that changed hands in the domain, newest first
+func GetMovesForDomain(domain Domain, limit int) ([]AssignmentMove, error) {
+
+	rows, err := db.Query("SELECT mv.id, mv.assignment_id, t.name, m.id, m.email, m.name, mv.to_minion, mv.reason, mv.moved_on FROM assignment_moves mv JOIN task_assignments ta ON ta.id = mv.assignment_id JOIN tasks t ON t.id = ta.task_id JOIN minions m ON m.id = mv.from_minion WHERE t.domain_id = $1 ORDER BY mv.moved_on DESC, mv.id DESC LIMIT $2", domain.ID, limit)
+	if err != nil {
+		log.Printf("Error reading moves: %q", err)
+		return nil, err
+	}
+
+	var result []AssignmentMove
+
+	defer rows.Close()
+	for rows.Next() {
+		var mv AssignmentMove
+
+		if err := rows.Scan(&mv.ID, &mv.AssignmentID, &mv.TaskName, &mv.From.ID, &mv.From.Email, &mv.From.Name, &mv.To, &mv.Reason, &mv.MovedOn); err != nil {
+			log.Printf("Error scanning move: %q", err)
+			return nil, err
+		}
+		result = append(result, mv)
+	}
+
+	return result, nil
+}
+
+// PendingAssignments returns what every member of the domain still has to do
+func (dtx *DomainTx) PendingAssignments() ([]TaskAssignment, error) {
+	return getPendingAssignmentsForDomain(dtx.tx, dtx.Domain)
+}
+
+// LastMoves returns when each card in the domain that changed hands last did so
+func (dtx *DomainTx) LastMoves() (map[uint32]time.Time, error) {
+
+	rows, err := dtx.tx.Query("SELECT mv.assignment_id, MAX(mv.moved_on) FROM assignment_moves mv JOIN task_assignments ta ON ta.id = mv.assignment_id JOIN tasks t ON t.id = ta.task_id WHERE t.domain_id = $1 GROUP BY mv.assignment_id", dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error reading moves: %q", err)
+		return nil, err
+	}
+
+	result := make(map[uint32]time.Time)
+
+	defer rows.Close()
+	for rows.Next() {
+		var assignmentID uint32
+		var movedOn time.Time
+
+		if err := rows.Scan(&assignmentID, &movedOn); err != nil {
+			log.Printf("Error scanning move: %q", err)
+			return nil, err
+		}
+		result[assignmentID] = movedOn
+	}
+
+	return result, nil
+}
+
+// AssignmentReassign hands a pending card to another member
+func (dtx *DomainTx) AssignmentReassign(assignment TaskAssignment, to Minion) error {
+
+	_, err := dtx.tx.Exec("UPDATE task_assignments SET minion_id = $1 WHERE id = $2 AND status = 'pending'", to.ID, assignment.ID)
+	if err != nil {
+		log.Printf("Error reassigning card: %q", err)
+		return err
+	}
+
+	return recordMove(dtx.tx, assignment.ID, uint32(assignment.MinionID.Int64), sql.NullInt64{Int64: int64(to.ID), Valid: true}, MovedByReassign)
+}
+
+// AssignmentReturn puts a pending card back into the deck. The assignment stays around until the
+// next reset, so whoever drew it doesn't get a new card for that day.
+func (dtx *DomainTx) AssignmentReturn(assignment TaskAssignment) error {
+
+	_, err := dtx.tx.Exec("UPDATE task_assignments SET previous_status = status, previous_snoozed_until = snoozed_until, status_changed_at = CURRENT_TIMESTAMP, status = 'returned' WHERE id = $1 AND status = 'pending'", assignment.ID)
+	if err != nil {
+		log.Printf("Error returning card: %q", err)
+		return err
+	}
+
+	return recordMove(dtx.tx, assignment.ID, uint32(assignment.MinionID.Int64), sql.NullInt64{}, MovedByReturn)
+}
+
+// AssignmentMovedBack records that the system put a card back in the deck, out of the hand of whoever had it
+func (dtx *DomainTx) AssignmentMovedBack(assignment TaskAssignment) error {
+
+	if !assignment.MinionID.Valid {
+		return nil
+	}
+
+	return recordMove(dtx.tx, assignment.ID, uint32(assignment.MinionID.Int64), sql.NullInt64{}, MovedBySystem)
+}
+
+// OfferToAll puts a card up for anyone to take until expiresOn, unless there already is an offer for it that hasn't expired
+func (dtx *DomainTx) OfferToAll(assignment TaskAssignment, today time.Time, expiresOn time.Time) error {
+
+	_, err := dtx.tx.Exec("INSERT INTO assignment_offers (domain_id, assignment_id, offered_by, expires_on) VALUES($1, $2, $3, $4) ON CONFLICT (assignment_id) DO UPDATE SET offered_by = EXCLUDED.offered_by, offered_to = NULL, swap_for = NULL, bounty = 0, created_on = CURRENT_TIMESTAMP, expires_on = EXCLUDED.expires_on WHERE assignment_offers.expires_on < $5", dtx.Domain.ID, assignment.ID, assignment.MinionID, util.StrDateFromTime(expiresOn), util.StrDateFromTime(today))
+	if err != nil {
+		log.Printf("Error creating offer: %q", err)
+		return err
+	}
+
+	return nil
+}
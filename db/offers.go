@@ -0,0 +1,184 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+var (
+	ErrOfferInvalid = errors.New("This offer has expired or was withdrawn")
+	ErrBountyUnpaid = errors.New("The bounty can't be paid, there aren't enough points left to pay it")
+)
+
+// GetPendingAssignmentsForDomain returns what every member still has to do, for picking something to swap for
+func GetPendingAssignmentsForDomain(domain Domain) ([]TaskAssignment, error) {
+	return getPendingAssignmentsForDomain(db, domain)
+}
+
+func getPendingAssignmentsForDomain(q querier, domain Domain) ([]TaskAssignment, error) {
+
+	today := util.StrDateFromTime(time.Now().In(domain.Location()))
+	rows, err := q.Query("SELECT ta.id, task_id, ta.minion_id, assigned_on, GREATEST(0, $2::date - assigned_on) AS days_old, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta JOIN tasks AS t ON ta.task_id = t.id WHERE ta.status = 'pending' AND t.domain_id = $1 ORDER BY ta.assigned_on, ta.id", domain.ID, today)
+	if err != nil {
+		log.Printf("Error reading pending assignments: %q", err)
+		return nil, err
+	}
+
+	return readAssignmentsFromRows(rows)
+}
+
+// OfferCreate puts an assignment up for a swap or a bounty, replacing any earlier offer for it
+func OfferCreate(offer Offer) error {
+
+	var swapFor sql.NullInt64
+	if offer.IsSwap() {
+		swapFor = sql.NullInt64{Int64: int64(offer.SwapFor.ID), Valid: true}
+	}
+
+	_, err := db.Exec("INSERT INTO assignment_offers (domain_id, assignment_id, offered_by, offered_to, swap_for, bounty, expires_on) VALUES($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (assignment_id) DO UPDATE SET offered_by = EXCLUDED.offered_by, offered_to = EXCLUDED.offered_to, swap_for = EXCLUDED.swap_for, bounty = EXCLUDED.bounty, created_on = CURRENT_TIMESTAMP, expires_on = EXCLUDED.expires_on", offer.DomainID, offer.Assignment.ID, offer.OfferedBy.ID, offer.OfferedTo, swapFor, offer.Bounty, util.StrDateFromTime(offer.ExpiresOn))
+	if err != nil {
+		log.Printf("Error creating offer: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetOffersForDomain returns the offers that can still be accepted: not expired, and both cards still
+// pending with the people that made and got the offer
+func GetOffersForDomain(domain Domain) ([]Offer, error) {
+
+	today := util.StrDateFromTime(time.Now().In(domain.Location()))
+	rows, err := db.Query("SELECT o.id, o.domain_id, o.offered_to, o.bounty, o.expires_on, m.id, m.email, m.name, ta.id, ta.task_id, ta.minion_id, ta.assigned_on, t.name, t.weekly, COALESCE(sw.id, 0), sw.task_id, sw.minion_id, sw.assigned_on, COALESCE(st.name, ''), COALESCE(st.weekly, false) FROM assignment_offers o JOIN minions m ON m.id = o.offered_by JOIN task_assignments ta ON ta.id = o.assignment_id JOIN tasks t ON t.id = ta.task_id LEFT JOIN task_assignments sw ON sw.id = o.swap_for LEFT JOIN tasks st ON st.id = sw.task_id WHERE o.domain_id = $1 AND o.expires_on >= $2 AND ta.status = 'pending' AND ta.minion_id = o.offered_by AND (o.swap_for IS NULL OR (sw.status = 'pending' AND sw.minion_id = o.offered_to)) ORDER BY o.created_on", domain.ID, today)
+	if err != nil {
+		log.Printf("Error reading offers: %q", err)
+		return nil, err
+	}
+
+	var result []Offer
+
+	defer rows.Close()
+	for rows.Next() {
+		var o Offer
+		var swapTaskID sql.NullInt64
+
+		if err := rows.Scan(&o.ID, &o.DomainID, &o.OfferedTo, &o.Bounty, &o.ExpiresOn, &o.OfferedBy.ID, &o.OfferedBy.Email, &o.OfferedBy.Name, &o.Assignment.ID, &o.Assignment.Task.ID, &o.Assignment.MinionID, &o.Assignment.AssignedDate, &o.Assignment.Task.Name, &o.Assignment.Task.Weekly, &o.SwapFor.ID, &swapTaskID, &o.SwapFor.MinionID, &o.SwapFor.AssignedDate, &o.SwapFor.Task.Name, &o.SwapFor.Task.Weekly); err != nil {
+			log.Printf("Error scanning offer: %q", err)
+			return nil, err
+		}
+		o.SwapFor.Task.ID = uint32(swapTaskID.Int64)
+		result = append(result, o)
+	}
+
+	return result, nil
+}
+
+// OfferCancel withdraws an offer, or declines it when it was a swap offered to the minion
+func OfferCancel(domain Domain, offerID uint32, minion Minion) error {
+
+	result, err := db.Exec("DELETE FROM assignment_offers WHERE id = $1 AND domain_id = $2 AND (offered_by = $3 OR offered_to = $3)", offerID, domain.ID, minion.ID)
+	if err != nil {
+		log.Printf("Error cancelling offer: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// OfferAccept hands the offered card to the minion, and either the card asked for in a swap to the
+// one that made the offer, or the bounty to the minion. All of it happens or nothing does.
+func OfferAccept(domain Domain, offerID uint32, minion Minion) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %q", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var assignmentID, offeredBy uint32
+	var offeredTo, swapFor sql.NullInt64
+	var bounty int
+
+	today := util.StrDateFromTime(time.Now().In(domain.Location()))
+	row := tx.QueryRow("SELECT assignment_id, offered_by, offered_to, swap_for, bounty FROM assignment_offers WHERE id = $1 AND domain_id = $2 AND expires_on >= $3 FOR UPDATE", offerID, domain.ID, today)
+	err = row.Scan(&assignmentID, &offeredBy, &offeredTo, &swapFor, &bounty)
+	if err == sql.ErrNoRows {
+		return ErrOfferInvalid
+	}
+	if err != nil {
+		log.Printf("Error reading offer: %q", err)
+		return err
+	}
+
+	if offeredBy == minion.ID || (offeredTo.Valid && offeredTo.Int64 != int64(minion.ID)) {
+		return ErrOfferInvalid
+	}
+
+	// either card could have been done or handed to someone else in the meantime
+	result, err := tx.Exec("UPDATE task_assignments SET minion_id = $1 WHERE id = $2 AND minion_id = $3 AND status = 'pending'", minion.ID, assignmentID, offeredBy)
+	if err != nil {
+		log.Printf("Error reassigning offered card: %q", err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrOfferInvalid
+	}
+
+	reason := MovedByOffer
+	if swapFor.Valid {
+		reason = MovedBySwap
+
+		result, err = tx.Exec("UPDATE task_assignments SET minion_id = $1 WHERE id = $2 AND minion_id = $3 AND status = 'pending'", offeredBy, swapFor, minion.ID)
+		if err != nil {
+			log.Printf("Error reassigning swapped card: %q", err)
+			return err
+		}
+		if count, _ := result.RowsAffected(); count == 0 {
+			return ErrOfferInvalid
+		}
+
+		err = recordMove(tx, uint32(swapFor.Int64), minion.ID, sql.NullInt64{Int64: int64(offeredBy), Valid: true}, reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordMove(tx, assignmentID, offeredBy, sql.NullInt64{Int64: int64(minion.ID), Valid: true}, reason)
+	if err != nil {
+		return err
+	}
+
+	if bounty > 0 {
+		result, err = tx.Exec("UPDATE minion_domain SET points = points - $1 WHERE domain_id = $2 AND minion_id = $3 AND points >= $1", bounty, domain.ID, offeredBy)
+		if err != nil {
+			log.Printf("Error paying bounty: %q", err)
+			return err
+		}
+		if count, _ := result.RowsAffected(); count == 0 {
+			return ErrBountyUnpaid
+		}
+		_, err = tx.Exec("UPDATE minion_domain SET points = points + $1 WHERE domain_id = $2 AND minion_id = $3", bounty, domain.ID, minion.ID)
+		if err != nil {
+			log.Printf("Error paying bounty: %q", err)
+			return err
+		}
+	}
+
+	// any other offers for these cards were made by or to the previous holder
+	_, err = tx.Exec("DELETE FROM assignment_offers WHERE assignment_id IN ($1, $2) OR swap_for IN ($1, $2)", assignmentID, swapFor)
+	if err != nil {
+		log.Printf("Error removing offers: %q", err)
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+// TaskUpdate saves the name, count, description and whether the task is weekly
+func (dtx *DomainTx) TaskUpdate(task Task) error {
+
+	_, err := dtx.tx.Exec("UPDATE tasks SET name = $1, weekly = $2, description = $3, count = $4 WHERE id = $5 AND domain_id = $6", task.Name, task.Weekly, task.Description, task.Count, task.ID, dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error updating task: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// TaskSetTags replaces the tags of the task, which TaskUpdate leaves alone since the deck editor doesn't show them
+func (dtx *DomainTx) TaskSetTags(task Task) error {
+
+	_, err := dtx.tx.Exec("UPDATE tasks SET tags = $1 WHERE id = $2 AND domain_id = $3", task.StoredTags(), task.ID, dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error updating task tags: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// TaskInsert adds a new task to the deck
+func (dtx *DomainTx) TaskInsert(task Task) error {
+
+	task.DomainID = dtx.Domain.ID
+	return createNewTask(dtx.tx, task)
+}
+
+// TaskDelete removes the task from the deck, along with every copy of it that was drawn
+func (dtx *DomainTx) TaskDelete(task Task) error {
+
+	_, err := dtx.tx.Exec("DELETE FROM tasks WHERE id = $1 AND domain_id = $2", task.ID, dtx.Domain.ID)
+	if err != nil {
+		log.Printf("Error deleting task: %q", err)
+		return err
+	}
+
+	return nil
+}
+
+// AssignmentsForTask returns every copy of the task drawn since the last reset, whatever its status, with their
+// ages as seen on today
+func (dtx *DomainTx) AssignmentsForTask(task Task, today time.Time) ([]TaskAssignment, error) {
+
+	rows, err := dtx.tx.Query("SELECT ta.id, task_id, ta.minion_id, assigned_on, GREATEST(0, $3::date - assigned_on) AS days_old, ta.status, ta.snoozed_until, COALESCE(ta.previous_status::text, ''), ta.previous_snoozed_until, ta.status_changed_at, t.domain_id, t.name, t.weekly, t.description FROM task_assignments AS ta JOIN tasks AS t ON ta.task_id = t.id WHERE ta.task_id = $1 AND t.domain_id = $2", task.ID, dtx.Domain.ID, util.StrDateFromTime(today))
+	if err != nil {
+		log.Printf("Error reading assignments for task: %q", err)
+		return nil, err
+	}
+
+	return readAssignmentsFromRows(rows)
+}
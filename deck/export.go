@@ -0,0 +1,374 @@
+package deck
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+// Format is a file format decks can be exported to and imported from
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	CSV  Format = "csv" // only the tasks, one per row
+)
+
+var Formats = []Format{JSON, YAML, CSV}
+
+func (format Format) IsValid() bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFor guesses the format of a file from its extension. A CSV file is taken to be an export of a deck,
+// not of a to-do app.
+func FormatFor(filename string) (Format, bool) {
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	switch ext {
+	case "yml":
+		ext = "yaml"
+	case "md", "markdown":
+		ext = string(Markdown)
+	}
+
+	format := Format(ext)
+	return format, format.IsValid() || format.IsForeign()
+}
+
+// ContentType is what a file in the format is served as
+func (format Format) ContentType() string {
+	switch format {
+	case JSON:
+		return "application/json"
+	case YAML:
+		return "application/x-yaml"
+	default:
+		return "text/csv"
+	}
+}
+
+// Deck is the definition of a domain as it is exported: what it looks like and its tasks, but none of its members
+// or cards that were drawn
+type Deck struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Icon        string     `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Colour      string     `json:"colour,omitempty" yaml:"colour,omitempty"`
+	Tasks       []DeckTask `json:"tasks" yaml:"tasks"`
+}
+
+type DeckTask struct {
+	Name        string   `json:"name" yaml:"name"`
+	Weekly      bool     `json:"weekly" yaml:"weekly"`
+	Count       uint32   `json:"count" yaml:"count"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// MaxTaskCount is the most copies of a task a deck can have, as in the deck editor
+const MaxTaskCount = 999
+
+const MaxTags = 10
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,50}$`)
+
+// RowError is what is wrong with one task in an imported file. Rows count from 1, not counting a CSV header.
+type RowError struct {
+	Row     int
+	Message string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("Row %d: %s", e.Row, e.Message)
+}
+
+// FromDomain makes the Deck for a domain and its tasks
+func FromDomain(domain Domain, tasks []Task) Deck {
+
+	result := Deck{
+		Name:        domain.Name,
+		Description: domain.Description.String,
+		Icon:        domain.Icon,
+		Colour:      domain.Colour,
+	}
+
+	for _, t := range tasks {
+		result.Tasks = append(result.Tasks, DeckTask{Name: t.Name, Weekly: t.Weekly, Count: t.Count, Description: t.Description.String, Tags: t.Tags})
+	}
+
+	return result
+}
+
+// ToTasks returns the tasks of the deck as they are stored
+func (d Deck) ToTasks() []Task {
+
+	var result []Task
+	for _, t := range d.Tasks {
+		result = append(result, Task{
+			Name:        t.Name,
+			Weekly:      t.Weekly,
+			Count:       t.Count,
+			Description: sql.NullString{String: t.Description, Valid: t.Description != ""},
+			Tags:        t.Tags,
+		})
+	}
+
+	return result
+}
+
+// Encode writes the deck in the format
+func Encode(w io.Writer, deck Deck, format Format) error {
+
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(deck)
+	case YAML:
+		out, err := yaml.Marshal(deck)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case CSV:
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"name", "weekly", "count", "description", "tags"})
+		for _, t := range deck.Tasks {
+			writer.Write([]string{t.Name, strconv.FormatBool(t.Weekly), strconv.Itoa(int(t.Count)), t.Description, strings.Join(t.Tags, " ")})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return fmt.Errorf("Unknown format: '%s'", format)
+}
+
+// Decode reads a deck in the format. When the file can be read but some of its tasks are wrong, those are
+// returned as RowErrors, all of them and not just the first. A CSV file only has tasks, so the deck has no name.
+func Decode(r io.Reader, format Format) (Deck, []RowError, error) {
+
+	var deck Deck
+	var rowErrors map[int]string
+	var err error
+
+	switch format {
+	case JSON, YAML:
+		deck, err = decodeDocument(r, format)
+	case CSV:
+		deck, rowErrors, err = decodeCSV(r)
+	default:
+		err = fmt.Errorf("Unknown format: '%s'", format)
+	}
+	if err != nil {
+		return deck, nil, err
+	}
+
+	return deck, validateTasks(deck.Tasks, rowErrors, nil), nil
+}
+
+// documentTask is a DeckTask as it is read, so a missing count can be told apart from 0
+type documentTask struct {
+	Name        string   `json:"name" yaml:"name"`
+	Weekly      bool     `json:"weekly" yaml:"weekly"`
+	Count       *uint32  `json:"count" yaml:"count"`
+	Description string   `json:"description" yaml:"description"`
+	Tags        []string `json:"tags" yaml:"tags"`
+}
+
+type document struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description" yaml:"description"`
+	Icon        string         `json:"icon" yaml:"icon"`
+	Colour      string         `json:"colour" yaml:"colour"`
+	Tasks       []documentTask `json:"tasks" yaml:"tasks"`
+}
+
+func decodeDocument(r io.Reader, format Format) (Deck, error) {
+
+	var doc document
+
+	if format == JSON {
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&doc); err != nil {
+			return Deck{}, err
+		}
+	} else {
+		in, err := ioutil.ReadAll(r)
+		if err != nil {
+			return Deck{}, err
+		}
+		if err := yaml.UnmarshalStrict(in, &doc); err != nil {
+			return Deck{}, err
+		}
+	}
+
+	deck := Deck{
+		Name:        strings.TrimSpace(doc.Name),
+		Description: strings.TrimSpace(doc.Description),
+		Icon:        strings.TrimSpace(doc.Icon),
+		Colour:      strings.TrimSpace(doc.Colour),
+	}
+	for _, t := range doc.Tasks {
+		count := uint32(1)
+		if t.Count != nil {
+			count = *t.Count
+		}
+		task := DeckTask{Name: strings.TrimSpace(t.Name), Weekly: t.Weekly, Count: count, Description: strings.TrimSpace(t.Description)}
+		for _, tag := range t.Tags {
+			task.Tags = appendTag(task.Tags, strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		}
+		deck.Tasks = append(deck.Tasks, task)
+	}
+
+	return deck, nil
+}
+
+// decodeCSV also returns the fields that couldn't be read, by row
+func decodeCSV(r io.Reader) (Deck, map[int]string, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return Deck{}, nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// spreadsheets like to start files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, found := columns["name"]; !found {
+		return Deck{}, nil, fmt.Errorf("The first row needs a 'name' column")
+	}
+
+	var deck Deck
+	rowErrors := make(map[int]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return deck, nil, err
+		}
+
+		field := func(column string) string {
+			if i, found := columns[column]; found && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := len(deck.Tasks) + 1
+		task := DeckTask{Name: field("name"), Description: field("description"), Count: 1}
+		for _, tag := range strings.Fields(field("tags")) {
+			task.Tags = appendTag(task.Tags, strings.ToLower(strings.TrimPrefix(tag, "#")))
+		}
+		if weekly := field("weekly"); weekly != "" {
+			if task.Weekly, err = parseBool(weekly); err != nil {
+				rowErrors[row] = "Weekly should be true or false"
+			}
+		}
+		if count := field("count"); count != "" {
+			n, err := strconv.ParseUint(count, 10, 32)
+			if err != nil {
+				rowErrors[row] = "Count should be a number"
+			}
+			task.Count = uint32(n)
+		}
+
+		deck.Tasks = append(deck.Tasks, task)
+	}
+
+	return deck, rowErrors, nil
+}
+
+// parseBool is strconv.ParseBool that also takes the yes and no spreadsheets like to use
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// Validate checks tasks that were changed after they were read, like in the preview of an import
+func Validate(tasks []DeckTask) []RowError {
+	return validateTasks(tasks, nil, nil)
+}
+
+// validateTasks checks the tasks along with the problems found reading them. Rows count the tasks from 1, unless
+// they are numbered by lines.
+func validateTasks(tasks []DeckTask, unreadable map[int]string, lines []int) []RowError {
+
+	var result []RowError
+	seen := make(map[string]int)
+
+	for i, t := range tasks {
+
+		row := i + 1
+		if lines != nil {
+			row = lines[i]
+		}
+		message := unreadable[row]
+
+		switch {
+		case message != "":
+		case t.Name == "":
+			message = "A task needs a name"
+		case utf8.RuneCountInString(t.Name) > 200:
+			message = "Task names are at most 200 characters"
+		case t.Count > MaxTaskCount:
+			message = fmt.Sprintf("Count should be at most %d", MaxTaskCount)
+		case len(t.Tags) > MaxTags:
+			message = fmt.Sprintf("At most %d tags", MaxTags)
+		}
+		for _, tag := range t.Tags {
+			if message == "" && !tagPattern.MatchString(tag) {
+				message = fmt.Sprintf("Tags are at most 50 letters, digits, - and _, not '%s'", tag)
+			}
+		}
+
+		if message == "" {
+			key := strings.ToLower(t.Name)
+			if first, duplicate := seen[key]; duplicate {
+				message = fmt.Sprintf("Same name as row %d", first)
+			} else {
+				seen[key] = row
+			}
+		}
+
+		if message != "" {
+			result = append(result, RowError{Row: row, Message: message})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package deck
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+
+	original := Deck{
+		Name:   "Tree House",
+		Icon:   "T",
+		Colour: "#aabbcc",
+		Tasks: []DeckTask{
+			{Name: "Sweep the floor", Count: 2, Tags: []string{"attic", "floor-2"}},
+			{Name: "Fix the roof, again", Weekly: true, Count: 1, Description: "before \"it\" rains"},
+		},
+	}
+
+	for _, format := range Formats {
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, original, format); err != nil {
+			t.Fatal(format, err)
+		}
+
+		deck, rowErrors, err := Decode(&buf, format)
+		if err != nil || len(rowErrors) != 0 || len(deck.Tasks) != 2 {
+			t.Fatal(format, err, rowErrors)
+		}
+		for i, task := range deck.Tasks {
+			if !reflect.DeepEqual(task, original.Tasks[i]) {
+				t.Error(format, task)
+			}
+		}
+		// CSV only has the tasks
+		if format != CSV && (deck.Name != original.Name || deck.Colour != original.Colour) {
+			t.Error(format, deck)
+		}
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+
+	in := "Description,Name,Weekly\n" +
+		"wipe shelves,Clean fridge,yes\n" +
+		",Water plants,\n" +
+		",,true\n" +
+		",Clean FRIDGE,false\n" +
+		",Mop,sometimes\n"
+
+	deck, rowErrors, err := Decode(strings.NewReader(in), CSV)
+	if err != nil || len(deck.Tasks) != 5 {
+		t.Fatal(err)
+	}
+	// no count column means one of each
+	if deck.Tasks[1].Count != 1 || deck.Tasks[1].Weekly || deck.Tasks[0].Description != "wipe shelves" {
+		t.Error(deck.Tasks)
+	}
+
+	// all problems are reported, not just the first
+	if len(rowErrors) != 3 || rowErrors[0].Row != 3 || rowErrors[1].Row != 4 || rowErrors[2].Row != 5 {
+		t.Error(rowErrors)
+	}
+
+	if _, _, err := Decode(strings.NewReader("task,count\nMop,1\n"), CSV); err == nil {
+		t.Error("no name column")
+	}
+}
+
+func TestDecodeDocument(t *testing.T) {
+
+	deck, rowErrors, err := Decode(strings.NewReader(`{"name": "X", "tasks": [{"name": "Mop"}, {"name": "Sweep", "count": 1000}]}`), JSON)
+	if err != nil || deck.Tasks[0].Count != 1 || len(rowErrors) != 1 || rowErrors[0].Row != 2 {
+		t.Error(err, deck, rowErrors)
+	}
+
+	deck, rowErrors, err = Decode(strings.NewReader("name: X\ntasks:\n  - name: Sweep\n    tags: ['#Hall', hall]\n  - name: Mop\n    tags: ['no spaces']\n"), YAML)
+	if err != nil || len(deck.Tasks[0].Tags) != 1 || deck.Tasks[0].Tags[0] != "hall" || len(rowErrors) != 1 || rowErrors[0].Row != 2 {
+		t.Error(err, deck, rowErrors)
+	}
+
+	// typos don't go unnoticed
+	if _, _, err := Decode(strings.NewReader("name: X\ntasks:\n  - name: Sweep\n    cuont: 1\n"), YAML); err == nil {
+		t.Error("unknown field")
+	}
+	if _, _, err := Decode(strings.NewReader(`{"name": "X", "taks": []}`), JSON); err == nil {
+		t.Error("unknown field")
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+
+	if f, ok := FormatFor("Chores.YML"); !ok || f != YAML {
+		t.Fail()
+	}
+	if f, ok := FormatFor("todo.md"); !ok || f != Markdown {
+		t.Fail()
+	}
+	if _, ok := FormatFor("chores.txt"); ok {
+		t.Fail()
+	}
+}
@@ -0,0 +1,279 @@
+package deck
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exports of other chore and to-do apps. These can only be imported, and how often they say something needs doing
+// only translates into a deck as a guess, so they are previewed before anything is saved.
+const (
+	TodoCSV  Format = "todo-csv" // one task per row, with a title and maybe notes and a recurrence
+	Markdown Format = "markdown" // a checklist, "- [ ] Clean fridge (every week)"
+)
+
+var ForeignFormats = []Format{TodoCSV, Markdown}
+
+var ErrNoChecklist = errors.New("No checklist items like '- [ ] Clean fridge' found")
+
+func (format Format) IsForeign() bool {
+	for _, f := range ForeignFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Guess is a task read from a foreign format, with a note about how its recurrence was read
+type Guess struct {
+	DeckTask
+	Note string
+}
+
+// DecodeForeign reads the tasks from a file in one of the ForeignFormats
+func DecodeForeign(r io.Reader, format Format) ([]Guess, error) {
+
+	switch format {
+	case TodoCSV:
+		return decodeTodoCSV(r)
+	case Markdown:
+		return decodeMarkdown(r)
+	}
+
+	return nil, fmt.Errorf("Unknown format: '%s'", format)
+}
+
+// the column names to-do apps use, in order of preference
+var (
+	titleColumns      = []string{"title", "task", "name", "content", "task name", "summary", "subject"}
+	notesColumns      = []string{"description", "notes", "note", "details"}
+	recurrenceColumns = []string{"recurrence", "repeat", "repeats", "recurring", "frequency", "rrule", "repeat rule", "due string"}
+)
+
+func decodeTodoCSV(r io.Reader) ([]Guess, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, found := columns[name]; !found {
+			columns[name] = i
+		}
+	}
+	pick := func(names []string) int {
+		for _, name := range names {
+			if i, found := columns[name]; found {
+				return i
+			}
+		}
+		return -1
+	}
+
+	title, notes, recurrence := pick(titleColumns), pick(notesColumns), pick(recurrenceColumns)
+	if title == -1 {
+		return nil, fmt.Errorf("The first row needs a column with the name of the task, like 'title' or 'task'")
+	}
+	// some apps put sections and comments in between the tasks
+	kind, hasKind := columns["type"]
+
+	var result []Guess
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		field := func(i int) string {
+			if i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		if hasKind && field(kind) != "" && !strings.EqualFold(field(kind), "task") {
+			continue
+		}
+		if field(title) == "" {
+			continue
+		}
+
+		guess := guessRecurrence(field(recurrence))
+		guess.Name, guess.Description = field(title), field(notes)
+		result = append(result, guess)
+	}
+
+	return result, nil
+}
+
+var (
+	checklistItem = regexp.MustCompile(`^(\s*)[-*+]\s+\[[ xX]\]\s+(.+)$`)
+	// "Clean fridge (every week)" or "Clean fridge - every week"
+	trailingRecurrence = regexp.MustCompile(`^(.+?)\s*(?:\(([^()]+)\)|\s[-–—]\s+(.+))$`)
+)
+
+// decodeMarkdown reads the checklist items of a Markdown file. Text indented under an item is its description,
+// and everything else is left out.
+func decodeMarkdown(r io.Reader) ([]Guess, error) {
+
+	var result []Guess
+	itemIndent := -1
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+
+		if match := checklistItem.FindStringSubmatch(line); match != nil {
+			name := strings.TrimSpace(match[2])
+
+			guess := guessRecurrence("")
+			if parts := trailingRecurrence.FindStringSubmatch(name); parts != nil {
+				if g := guessRecurrence(parts[2] + parts[3]); g.Note != notUnderstood {
+					guess, name = g, parts[1]
+				}
+			}
+			guess.Name = name
+
+			result = append(result, guess)
+			itemIndent = len(match[1])
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if itemIndent >= 0 && line != "" && indent > itemIndent {
+			text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*+"))
+			last := &result[len(result)-1]
+			last.Description = strings.TrimSpace(last.Description + " " + text)
+			continue
+		}
+
+		itemIndent = -1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, ErrNoChecklist
+	}
+
+	return result, nil
+}
+
+const (
+	notUnderstood = "Didn't understand how often, so one card"
+	noRecurrence  = "No recurrence, so one card"
+)
+
+var weekdays = regexp.MustCompile(`\b(mon|tue|wed|thu|fri|sat|sun)[a-z]*\b`)
+
+// recurrences map what to-do apps call how often something happens to how many cards that is in a week.
+// Chores done once a week or less often are a single weekly card, anything more often is that many day cards.
+var recurrences = []struct {
+	pattern *regexp.Regexp
+	count   func(n int) int // 0 means a weekly card
+}{
+	{regexp.MustCompile(`^(daily|every ?day|each day)$`), func(int) int { return 7 }},
+	{regexp.MustCompile(`^every (week|work) ?days?$|^weekdays$`), func(int) int { return 5 }},
+	{regexp.MustCompile(`^every other day$`), func(int) int { return 4 }},
+	{regexp.MustCompile(`^every (\d+) days?$`), func(n int) int { return (7 + n - 1) / n }},
+	{regexp.MustCompile(`^(\d+) ?(?:x|times) (?:a|per|every|/) ?week$|^(\d+)x ?/ ?week$`), func(n int) int { return n }},
+	{regexp.MustCompile(`^twice (a|per|every) week$`), func(int) int { return 2 }},
+	{regexp.MustCompile(`^(three times|thrice) (a|per|every) week$`), func(int) int { return 3 }},
+	{regexp.MustCompile(`^(weekly|once (a|per) week|every week|each week|every other week|biweekly|bi-weekly|fortnightly|every \d+ weeks)$`), func(int) int { return 0 }},
+	{regexp.MustCompile(`^(monthly|every month|every other month|every \d+ months|quarterly|yearly|annually|every year)$`), func(int) int { return 0 }},
+}
+
+// guessRecurrence turns a recurrence like "every 3 days", "twice a week" or an RRULE into a weekly flag and count
+func guessRecurrence(recurrence string) Guess {
+
+	s := strings.ToLower(strings.TrimSpace(recurrence))
+	s = strings.TrimPrefix(s, "rrule:")
+	if s == "" {
+		return Guess{DeckTask: DeckTask{Count: 1}, Note: noRecurrence}
+	}
+
+	count, understood := -1, false
+	if strings.HasPrefix(s, "freq=") {
+		count, understood = rruleCount(s)
+	} else {
+		s = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimPrefix(s, "repeats "), "repeat ")), " ")
+		for _, r := range recurrences {
+			if match := r.pattern.FindStringSubmatch(s); match != nil {
+				n := 1
+				for _, group := range match[1:] {
+					if i, err := strconv.Atoi(group); err == nil && i > 0 {
+						n = i
+					}
+				}
+				count, understood = r.count(n), true
+				break
+			}
+		}
+		// "every monday" or "every tue and fri"
+		if !understood && (strings.HasPrefix(s, "every ") || strings.HasPrefix(s, "on ")) {
+			if days := weekdays.FindAllString(s, -1); len(days) > 0 {
+				count, understood = len(days), true
+			}
+		}
+	}
+
+	if !understood {
+		return Guess{DeckTask: DeckTask{Count: 1}, Note: notUnderstood}
+	}
+	if count > 7 {
+		count = 7
+	}
+	if count <= 1 {
+		return Guess{DeckTask: DeckTask{Weekly: true, Count: 1}, Note: fmt.Sprintf("'%s' is one weekly card", recurrence)}
+	}
+
+	return Guess{DeckTask: DeckTask{Count: uint32(count)}, Note: fmt.Sprintf("'%s' is %d cards", recurrence, count)}
+}
+
+// rruleCount reads an iCalendar RRULE like "FREQ=WEEKLY;BYDAY=MO,TH"
+func rruleCount(rule string) (int, bool) {
+
+	parts := make(map[string]string)
+	for _, part := range strings.Split(rule, ";") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			parts[kv[0]] = kv[1]
+		}
+	}
+
+	interval, err := strconv.Atoi(parts["interval"])
+	if err != nil || interval < 1 {
+		interval = 1
+	}
+
+	switch parts["freq"] {
+	case "daily":
+		return (7 + interval - 1) / interval, true
+	case "weekly":
+		if interval == 1 && parts["byday"] != "" {
+			return len(strings.Split(parts["byday"], ",")), true
+		}
+		return 0, true
+	case "monthly", "yearly":
+		return 0, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package deck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGuessRecurrence(t *testing.T) {
+
+	cases := []struct {
+		recurrence string
+		weekly     bool
+		count      uint32
+	}{
+		{"", false, 1},
+		{"whenever I feel like it", false, 1},
+		{"Daily", false, 7},
+		{"every weekday", false, 5},
+		{"every 3 days", false, 3},
+		{"every other day", false, 4},
+		{"twice a week", false, 2},
+		{"3 times per week", false, 3},
+		{"Every Tue and Fri", false, 2},
+		{"every monday", true, 1},
+		{"weekly", true, 1},
+		{"every 2 weeks", true, 1},
+		{"monthly", true, 1},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR", false, 3},
+		{"FREQ=DAILY;INTERVAL=2", false, 4},
+		{"FREQ=MONTHLY", true, 1},
+	}
+
+	for _, c := range cases {
+		guess := guessRecurrence(c.recurrence)
+		if guess.Weekly != c.weekly || guess.Count != c.count || guess.Note == "" {
+			t.Error(c.recurrence, guess)
+		}
+	}
+}
+
+func TestDecodeTodoCSV(t *testing.T) {
+
+	in := "TYPE,CONTENT,NOTES,Repeat\n" +
+		"section,Kitchen,,\n" +
+		"task,Clean fridge,wipe shelves too,every week\n" +
+		"task,Dishes,,daily\n" +
+		"task,,,\n"
+
+	guesses, err := DecodeForeign(strings.NewReader(in), TodoCSV)
+	if err != nil || len(guesses) != 2 {
+		t.Fatal(err, guesses)
+	}
+	if guesses[0].Name != "Clean fridge" || !guesses[0].Weekly || guesses[0].Description != "wipe shelves too" {
+		t.Error(guesses[0])
+	}
+	if guesses[1].Weekly || guesses[1].Count != 7 {
+		t.Error(guesses[1])
+	}
+
+	if _, err := DecodeForeign(strings.NewReader("when,where\nnow,here\n"), TodoCSV); err == nil {
+		t.Error("no title column")
+	}
+}
+
+func TestDecodeMarkdown(t *testing.T) {
+
+	in := `# Chores
+
+## Kitchen
+- [ ] Clean fridge (every week)
+  wipe the shelves too
+- [x] Dishes - daily
+* [ ] Descale kettle (the blue one)
+
+Some notes that are not a task.
+  - [ ] Water plants
+`
+
+	guesses, err := DecodeForeign(strings.NewReader(in), Markdown)
+	if err != nil || len(guesses) != 4 {
+		t.Fatal(err, guesses)
+	}
+	if guesses[0].Name != "Clean fridge" || !guesses[0].Weekly || guesses[0].Description != "wipe the shelves too" {
+		t.Error(guesses[0])
+	}
+	if guesses[1].Name != "Dishes" || guesses[1].Count != 7 {
+		t.Error(guesses[1])
+	}
+	// parentheses that aren't a recurrence stay in the name
+	if guesses[2].Name != "Descale kettle (the blue one)" || guesses[2].Count != 1 {
+		t.Error(guesses[2])
+	}
+	if guesses[3].Name != "Water plants" || guesses[3].Description != "" {
+		t.Error(guesses[3])
+	}
+
+	if _, err := DecodeForeign(strings.NewReader("# Nothing to do\n"), Markdown); err != ErrNoChecklist {
+		t.Error(err)
+	}
+}
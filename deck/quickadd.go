@@ -0,0 +1,88 @@
+package deck
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// pasted lists often still have their bullets or numbers
+	listMarker = regexp.MustCompile(`^(?:[-*+](?:\s+\[[ xX]\])?|\d+[.)])\s+`)
+	countHint  = regexp.MustCompile(`^[x×](\d+)$`)
+)
+
+// ParseQuickAdd reads a pasted list of tasks, one per line, with hints after the name:
+//
+//	Clean fridge @weekly x2 #kitchen -- wipe shelves too
+//
+// @weekly or @daily says whether it's a weekly task, x2 is the number of copies and #kitchen adds a tag.
+// Everything after -- is the description. Blank lines are skipped, so the rows of the RowErrors are line numbers.
+func ParseQuickAdd(text string) ([]DeckTask, []RowError) {
+
+	var tasks []DeckTask
+	var lines []int
+	unreadable := make(map[int]string)
+
+	for i, line := range strings.Split(text, "\n") {
+
+		line = strings.TrimSpace(listMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line == "" {
+			continue
+		}
+
+		task := DeckTask{Count: 1}
+		if parts := strings.SplitN(line, "--", 2); len(parts) == 2 {
+			line, task.Description = parts[0], strings.TrimSpace(parts[1])
+		}
+
+		var name []string
+		var problem string
+		var sawCount, sawWeekly bool
+		for _, word := range strings.Fields(line) {
+
+			lower := strings.ToLower(word)
+			switch {
+			case lower == "@weekly" || lower == "@daily":
+				if sawWeekly {
+					problem = "Only one of @weekly or @daily"
+				}
+				task.Weekly, sawWeekly = lower == "@weekly", true
+			case strings.HasPrefix(word, "@"):
+				problem = fmt.Sprintf("Unknown hint '%s', use @weekly or @daily", word)
+			case countHint.MatchString(word):
+				n, err := strconv.ParseUint(countHint.FindStringSubmatch(word)[1], 10, 32)
+				if err != nil || sawCount {
+					problem = fmt.Sprintf("Only one count like x2, not '%s'", word)
+				}
+				task.Count, sawCount = uint32(n), true
+			case strings.HasPrefix(word, "#") && len(word) > 1:
+				task.Tags = appendTag(task.Tags, lower[1:])
+			default:
+				name = append(name, word)
+			}
+		}
+		task.Name = strings.Join(name, " ")
+
+		if problem != "" {
+			unreadable[i+1] = problem
+		}
+		tasks = append(tasks, task)
+		lines = append(lines, i+1)
+	}
+
+	return tasks, validateTasks(tasks, unreadable, lines)
+}
+
+// appendTag adds the tag unless the task has it already
+func appendTag(tags []string, tag string) []string {
+
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+
+	return append(tags, tag)
+}
@@ -0,0 +1,62 @@
+package deck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+
+	text := `Clean fridge @weekly x2 #kitchen #Kitchen -- wipe shelves too
+- Water plants
+
+1. Take out the bins x3 #outside
+- [ ] Dishes @daily -- after dinner -- every day`
+
+	tasks, rowErrors := ParseQuickAdd(text)
+	if len(rowErrors) != 0 || len(tasks) != 4 {
+		t.Fatal(tasks, rowErrors)
+	}
+
+	expected := []DeckTask{
+		{Name: "Clean fridge", Weekly: true, Count: 2, Tags: []string{"kitchen"}, Description: "wipe shelves too"},
+		{Name: "Water plants", Count: 1},
+		{Name: "Take out the bins", Count: 3, Tags: []string{"outside"}},
+		{Name: "Dishes", Count: 1, Description: "after dinner -- every day"},
+	}
+	for i, task := range tasks {
+		if !reflect.DeepEqual(task, expected[i]) {
+			t.Error(i, task)
+		}
+	}
+}
+
+func TestParseQuickAddErrors(t *testing.T) {
+
+	text := `Mop
+
+@monthly chores
+@weekly x2
+Sweep x2 x3
+MOP
+Fix X11 forwarding`
+
+	tasks, rowErrors := ParseQuickAdd(text)
+	if len(tasks) != 6 || tasks[5].Name != "Fix X11 forwarding" {
+		t.Error(tasks)
+	}
+
+	// rows are lines, blank ones included
+	rows := []int{3, 4, 5, 6}
+	if len(rowErrors) != len(rows) {
+		t.Fatal(rowErrors)
+	}
+	for i, row := range rows {
+		if rowErrors[i].Row != row {
+			t.Error(rowErrors[i])
+		}
+	}
+	if rowErrors[3].Message != "Same name as row 1" {
+		t.Error(rowErrors[3])
+	}
+}
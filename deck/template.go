@@ -0,0 +1,134 @@
+package deck
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+var (
+	ErrNoTemplateName  = errors.New("A deck template needs a name")
+	ErrNoTemplateTasks = errors.New("A deck template needs tasks")
+)
+
+// Template is a ready-made deck that new domains can start from
+type Template struct {
+	ID          string `yaml:"-"` // the file name without extension
+	Name        string
+	Description string
+	Tasks       []TemplateTask
+}
+
+// TemplateTask is a task in a Template, with the number of copies for a single member
+type TemplateTask struct {
+	Name        string
+	Weekly      bool
+	Count       uint32
+	Description string
+	Fixed       bool // the count doesn't grow with the number of members, there is only one fridge
+}
+
+// Library is every template in the decks directory, sorted by name
+var Library []Template
+
+// LoadLibrary reads all the *.yaml templates in the directory into the Library
+func LoadLibrary(dir string) error {
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+
+	var library []Template
+	for _, file := range files {
+
+		in, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		template, err := parseTemplate(id, in)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		library = append(library, template)
+	}
+
+	sort.Slice(library, func(i, j int) bool { return library[i].Name < library[j].Name })
+	Library = library
+
+	return nil
+}
+
+// FindTemplate looks up a template in the Library
+func FindTemplate(id string) (Template, bool) {
+
+	for _, t := range Library {
+		if t.ID == id {
+			return t, true
+		}
+	}
+
+	return Template{}, false
+}
+
+func parseTemplate(id string, in []byte) (Template, error) {
+
+	var template Template
+	err := yaml.UnmarshalStrict(in, &template)
+	if err != nil {
+		return template, err
+	}
+	template.ID = id
+
+	if strings.TrimSpace(template.Name) == "" {
+		return template, ErrNoTemplateName
+	}
+	if len(template.Tasks) == 0 {
+		return template, ErrNoTemplateTasks
+	}
+	for i, task := range template.Tasks {
+		if strings.TrimSpace(task.Name) == "" || task.Count == 0 {
+			return template, fmt.Errorf("task %d needs a name and a count", i+1)
+		}
+	}
+
+	return template, nil
+}
+
+// TasksFor returns the tasks of the template for a domain shared by a number of members
+func (t Template) TasksFor(members int) []Task {
+
+	if members < 1 {
+		members = 1
+	}
+
+	var result []Task
+	for _, task := range t.Tasks {
+
+		description := strings.TrimSpace(task.Description)
+
+		count := task.Count
+		if !task.Fixed {
+			count *= uint32(members)
+		}
+
+		result = append(result, Task{
+			Name:        task.Name,
+			Weekly:      task.Weekly,
+			Count:       count,
+			Description: sql.NullString{String: description, Valid: description != ""},
+		})
+	}
+
+	return result
+}
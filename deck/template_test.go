@@ -0,0 +1,74 @@
+package deck
+
+import (
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+
+	in := []byte(`
+name: Tree House
+tasks:
+  - name: Sweep the floor
+    count: 2
+  - name: Fix the roof
+    weekly: true
+    count: 1
+    fixed: true
+    description: before it rains
+`)
+
+	template, err := parseTemplate("tree_house", in)
+	if err != nil || template.ID != "tree_house" || len(template.Tasks) != 2 || !template.Tasks[1].Fixed {
+		t.Fail()
+	}
+
+	if _, err := parseTemplate("x", []byte("name: Empty\n")); err != ErrNoTemplateTasks {
+		t.Fail()
+	}
+	if _, err := parseTemplate("x", []byte("tasks:\n  - name: Sweep\n    count: 1\n")); err != ErrNoTemplateName {
+		t.Fail()
+	}
+	if _, err := parseTemplate("x", []byte("name: X\ntasks:\n  - name: Sweep\n")); err == nil {
+		t.Fail()
+	}
+
+	// typos don't go unnoticed
+	if _, err := parseTemplate("x", []byte("name: X\ntasks:\n  - name: Sweep\n    cuont: 1\n")); err == nil {
+		t.Fail()
+	}
+}
+
+func TestTasksFor(t *testing.T) {
+
+	template := Template{Name: "Tree House", Tasks: []TemplateTask{
+		TemplateTask{Name: "Sweep the floor", Count: 2},
+		TemplateTask{Name: "Fix the roof", Weekly: true, Count: 1, Fixed: true, Description: "before it rains"},
+	}}
+
+	tasks := template.TasksFor(3)
+	if len(tasks) != 2 || tasks[0].Count != 6 || tasks[1].Count != 1 || !tasks[1].Weekly || tasks[1].Description.String != "before it rains" || tasks[0].Description.Valid {
+		t.Fail()
+	}
+
+	if template.TasksFor(0)[0].Count != 2 {
+		t.Fail()
+	}
+}
+
+func TestLoadLibrary(t *testing.T) {
+
+	err := LoadLibrary("../decks")
+	if err != nil || len(Library) < 4 {
+		t.Fail()
+	}
+
+	template, found := FindTemplate("housekeeping")
+	if !found || template.Name != "Housekeeping" {
+		t.Fail()
+	}
+
+	if _, found := FindTemplate("castle"); found {
+		t.Fail()
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/logic"
+)
+
+// AuctionHandler shows the cards everyone got in the last auction and lets you bid on the next one
+func AuctionHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	if domain.AssignmentMode != Auction {
+		ErrorHandler(c, "This domain doesn't use an auction", nil)
+		return
+	}
+
+	tasks, err := db.GetTasksForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return
+	}
+	domain.Members, err = db.GetMembersForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return
+	}
+	allocations, err := db.GetAllocationsForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+	myBids, err := db.GetBidsForMinion(domain, minion)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
+	bids := make(map[uint32]Bid)
+	for _, bid := range myBids {
+		bids[bid.TaskID] = bid
+	}
+
+	points := 0
+	for _, member := range domain.Members {
+		if member.ID == minion.ID {
+			points = member.Points
+		}
+	}
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "auction.tmpl.html", gin.H{
+		"minion":       minion,
+		"domain":       domain,
+		"domains":      domains,
+		"tasks":        tasks,
+		"bids":         bids,
+		"points":       points,
+		"allocations":  allocations,
+		"canBid":       domain.Role.Can(DrawCards),
+		"canEditTasks": domain.Role.Can(EditTasks),
+	})
+}
+
+// AuctionBidHandler places a bid to avoid or claim a task in the next auction
+func AuctionBidHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramTaskID, presentTaskID := c.GetPostForm("task_id")
+	paramPoints, presentPoints := c.GetPostForm("points")
+	if !presentDomainID || !presentTaskID || !presentPoints {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, DrawCards)
+	if !allowed {
+		return
+	}
+
+	taskID, err := strconv.Atoi(paramTaskID)
+	if err != nil || taskID < 0 {
+		ErrorHandler(c, "Invalid task ID", err)
+		return
+	}
+	points, err := strconv.Atoi(paramPoints)
+	if err != nil || points < 0 {
+		ErrorHandler(c, "Invalid number of points", err)
+		return
+	}
+	if c.DefaultPostForm("kind", "avoid") == "claim" {
+		points = -points
+	}
+
+	err = logic.PlaceBid(minion, domain, Bid{TaskID: uint32(taskID), Points: points})
+	if err == logic.ErrNotEnoughPoints || err == logic.ErrNoSuchTask {
+		ErrorHandler(c, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		ErrorHandler(c, "Error placing bid", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	AuctionHandler(c)
+}
+
+// AuctionHoldHandler hands out the cards right away, for when a domain switches to auction mode halfway through a period
+func AuctionHoldHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	if domain.AssignmentMode != Auction {
+		ErrorHandler(c, "This domain doesn't use an auction", nil)
+		return
+	}
+
+	err := logic.HoldAuction(domain)
+	if err != nil {
+		ErrorHandler(c, "Error holding auction", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	AuctionHandler(c)
+}
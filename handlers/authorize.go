@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+// currentMinion loads the logged in Minion, rendering an error page if they can't be found
+func currentMinion(c *gin.Context) (Minion, bool) {
+
+	session := sessions.Default(c)
+	userEmail := session.Get("user-id").(string)
+	var minion Minion
+	found := db.LoadMinion(userEmail, &minion)
+	if !found {
+		ErrorHandler(c, "User authenticated but not found", nil)
+	}
+
+	return minion, found
+}
+
+// authorizeDomain loads the domain and checks the minion has a role in it that allows the permission.
+// This is the only place handlers should decide who can do what with a domain. Only members get in this way,
+// public domains are shown to everyone else through their share link.
+// Renders an error page and returns false if not allowed.
+func authorizeDomain(c *gin.Context, minion Minion, paramDomainID string, permission Permission) (Domain, bool) {
+
+	domainID, err := strconv.Atoi(paramDomainID)
+	if err != nil || domainID < 0 {
+		ErrorHandler(c, fmt.Sprintf("Invalid Domain ID: '%s'", paramDomainID), err)
+		return Domain{}, false
+	}
+
+	domain, err := db.GetDomainByID(uint32(domainID))
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return domain, false
+	}
+
+	role, isMember := db.GetMembership(domain, minion)
+	if !isMember {
+		// not found rather than not allowed to avoid leaking domain IDs
+		ErrorHandler(c, "Domain not found", nil)
+		return domain, false
+	}
+	domain.Role = role
+
+	if !role.Can(permission) {
+		ErrorHandler(c, "You are not allowed to do that", nil)
+		return domain, false
+	}
+
+	return domain, true
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+// SetupAwayHandler adds a period you don't draw cards, in one domain or in all of them.
+// It can be in the past, to stop missed days from being filled in.
+func SetupAwayHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	startsOn, validStart := parseDate(c.PostForm("starts_on"))
+	endsOn, validEnd := parseDate(c.PostForm("ends_on"))
+	if !validStart || !validEnd || endsOn.Before(startsOn) {
+		ErrorHandler(c, "Invalid dates", nil)
+		return
+	}
+
+	period := AwayPeriod{MinionID: minion.ID, StartsOn: startsOn, EndsOn: endsOn}
+
+	// empty means all domains
+	if paramDomainID := c.PostForm("domain_id"); paramDomainID != "" {
+		domain, allowed := authorizeDomain(c, minion, paramDomainID, ViewBoard)
+		if !allowed {
+			return
+		}
+		period.DomainID = sql.NullInt64{Int64: int64(domain.ID), Valid: true}
+	}
+
+	err := db.AwayPeriodCreate(period)
+	if err != nil {
+		ErrorHandler(c, "Error adding away period", err)
+		return
+	}
+
+	renderSetup(c, minion, fmt.Sprintf("You are away from %s until %s.", startsOn.Format("January 2"), endsOn.Format("January 2")))
+}
+
+func SetupAwayRemoveHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	periodID, err := strconv.Atoi(c.Param("away_id"))
+	if err != nil || periodID < 0 {
+		ErrorHandler(c, "Invalid away period ID", err)
+		return
+	}
+
+	err = db.AwayPeriodDelete(minion, uint32(periodID))
+	if err != nil {
+		ErrorHandler(c, "No such away period", err)
+		return
+	}
+
+	renderSetup(c, minion, "")
+}
+
+// SetupBackfillHandler sets how many days back missed cards are still drawn, empty for all of them
+func SetupBackfillHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	var days sql.NullInt64
+	if paramDays := strings.TrimSpace(c.PostForm("days")); paramDays != "" {
+		n, err := strconv.Atoi(paramDays)
+		if err != nil || n < 0 {
+			ErrorHandler(c, "Invalid number of days", err)
+			return
+		}
+		days = sql.NullInt64{Int64: int64(n), Valid: true}
+	}
+
+	err := db.MinionSetBackfill(minion, days)
+	if err != nil {
+		ErrorHandler(c, "Error updating backfill", err)
+		return
+	}
+	minion.BackfillDays = days
+
+	renderSetup(c, minion, "")
+}
+
+// parseDate reads a date as sent by a date input, YYYY-MM-DD
+func parseDate(value string) (time.Time, bool) {
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	return date, err == nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/logic"
+)
+
+// bulkRequest comes from the overdue list on the overview page, or as JSON from a script
+type bulkRequest struct {
+	Action            string   `form:"action" json:"action"`
+	TaskAssignmentIDs []uint32 `form:"task_assignment_id" json:"task_assignment_id"`
+	Keep              int      `form:"keep" json:"keep"`
+}
+
+// TaskBulkHandler dismisses, completes or thins out a list of your overdue cards in one go.
+// Either all of them change or none do.
+func TaskBulkHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	asJSON := c.ContentType() == gin.MIMEJSON
+
+	var request bulkRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		bulkError(c, asJSON, "Invalid parameters", err)
+		return
+	}
+
+	// only overdue cards, as the overview shows them
+	pending := db.AssignmentRetrieveForMinion(minion, false)
+	now := time.Now().In(minion.Location())
+	calendarFor := calendarsFor(minion, db.GetDomainsForMinion(minion))
+
+	changes, err := logic.PlanBulkAction(logic.BulkAction(request.Action), pending, request.TaskAssignmentIDs, request.Keep, now, calendarFor)
+	if err != nil {
+		bulkError(c, asJSON, err.Error(), nil)
+		return
+	}
+
+	err = db.AssignmentsBulkUpdate(minion, changes)
+	if err != nil {
+		bulkError(c, asJSON, "Error updating assignments", err)
+		return
+	}
+
+	if asJSON {
+		c.JSON(http.StatusOK, gin.H{"changed": len(changes)})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/today")
+}
+
+func bulkError(c *gin.Context, asJSON bool, message string, err error) {
+
+	if !asJSON {
+		ErrorHandler(c, message, err)
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/logic"
+)
+
+// DraftHandler shows who picked what so far and, when it's your turn, lets you pick a card
+func DraftHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	if domain.AssignmentMode != Draft {
+		ErrorHandler(c, "This domain doesn't use a draft", nil)
+		return
+	}
+
+	draft, err := logic.LoadDraft(domain)
+	if err != nil {
+		ErrorHandler(c, "Error loading draft", err)
+		return
+	}
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "draft.tmpl.html", gin.H{
+		"minion":  minion,
+		"domains": domains,
+		"draft":   draft,
+		"my_turn": draft.IsTurnOf(minion),
+	})
+}
+
+// DraftPickHandler claims a card in the draft for the rest of the period
+func DraftPickHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramTaskID, presentTaskID := c.GetPostForm("task_id")
+	if !presentDomainID || !presentTaskID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, DrawCards)
+	if !allowed {
+		return
+	}
+
+	taskID, err := strconv.Atoi(paramTaskID)
+	if err != nil || taskID < 0 {
+		ErrorHandler(c, "Invalid task ID", err)
+		return
+	}
+
+	err = logic.DraftPick(minion, domain, uint32(taskID))
+	if err == logic.ErrNotYourTurn || err == logic.ErrCardGone {
+		ErrorHandler(c, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		ErrorHandler(c, "Error picking card", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DraftHandler(c)
+}
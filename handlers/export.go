@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/deck"
+	"github.com/niven/taskmaster/logic"
+)
+
+// maxImportSize is plenty for a deck of a thousand tasks
+const maxImportSize = 1 << 20
+
+var unsafeFilename = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+// DomainExportHandler downloads a deck and its tasks as JSON, YAML or CSV, so it can be kept or imported elsewhere
+func DomainExportHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	format := deck.Format(c.Param("format"))
+	if !format.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Unknown format: '%s'", c.Param("format")), nil)
+		return
+	}
+
+	tasks, err := db.GetTasksForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Error reading tasks", err)
+		return
+	}
+
+	filename := strings.Trim(unsafeFilename.ReplaceAllString(domain.Name, "_"), "_")
+	if filename == "" {
+		filename = "deck"
+	}
+
+	// encode it all first, so a failure can still be an error page instead of half a file
+	var out bytes.Buffer
+	err = deck.Encode(&out, deck.FromDomain(domain, tasks), format)
+	if err != nil {
+		ErrorHandler(c, "Error exporting domain", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", filename, format))
+	c.Data(http.StatusOK, format.ContentType(), out.Bytes())
+}
+
+// DomainImportHandler reads an uploaded deck. With a domain_id the tasks are merged into that deck, otherwise it
+// becomes a new deck. Nothing is saved when any of the tasks in the file are wrong. Exports of other apps are
+// shown in a preview first, since how often their tasks need doing is guessed.
+func DomainImportHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		ErrorHandler(c, "Missing file", err)
+		return
+	}
+	if header.Size > maxImportSize {
+		ErrorHandler(c, "That file is too big to be a deck", nil)
+		return
+	}
+
+	format := deck.Format(c.PostForm("format"))
+	if format == "" {
+		format, _ = deck.FormatFor(header.Filename)
+	}
+	if !format.IsValid() && !format.IsForeign() {
+		ErrorHandler(c, fmt.Sprintf("Unknown format for '%s', use a .json, .yaml, .csv or .md file", header.Filename), nil)
+		return
+	}
+
+	// check before reading the file, so nobody learns anything about decks they can't change
+	var domain Domain
+	paramDomainID, merge := c.GetPostForm("domain_id")
+	if merge {
+		var allowed bool
+		domain, allowed = authorizeDomain(c, minion, paramDomainID, EditTasks)
+		if !allowed {
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ErrorHandler(c, "Error reading file", err)
+		return
+	}
+	defer file.Close()
+
+	if format.IsForeign() {
+		guesses, err := deck.DecodeForeign(file, format)
+		if err != nil {
+			ErrorHandler(c, fmt.Sprintf("Could not read '%s'", header.Filename), err)
+			return
+		}
+
+		// setup menu needs the list
+		domains := db.GetDomainsForMinion(minion)
+
+		c.HTML(http.StatusOK, "import_preview.tmpl.html", gin.H{
+			"minion":   minion,
+			"domains":  domains,
+			"domain":   domain,
+			"merge":    merge,
+			"name":     strings.TrimSpace(c.PostForm("name")),
+			"filename": header.Filename,
+			"guesses":  guesses,
+			"max":      deck.MaxTaskCount,
+		})
+		return
+	}
+
+	imported, rowErrors, err := deck.Decode(file, format)
+	if err != nil {
+		ErrorHandler(c, fmt.Sprintf("Could not read '%s' as %s", header.Filename, strings.ToUpper(string(format))), err)
+		return
+	}
+	if len(rowErrors) > 0 {
+		importErrorHandler(c, header.Filename, rowErrors)
+		return
+	}
+
+	// the name in the form wins, since a CSV file doesn't have one
+	if name := strings.TrimSpace(c.PostForm("name")); name != "" {
+		imported.Name = name
+	}
+
+	saveImport(c, minion, domain, merge, imported)
+}
+
+// DomainImportConfirmHandler saves the tasks from the preview of an import, as they were changed there
+func DomainImportConfirmHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	var domain Domain
+	paramDomainID, merge := c.GetPostForm("domain_id")
+	if merge {
+		var allowed bool
+		domain, allowed = authorizeDomain(c, minion, paramDomainID, EditTasks)
+		if !allowed {
+			return
+		}
+	}
+
+	imported := deck.Deck{Name: strings.TrimSpace(c.PostForm("name"))}
+	for _, row := range c.PostFormArray("row") {
+
+		if c.PostForm("skip_"+row) == "true" {
+			continue
+		}
+
+		count, err := strconv.ParseUint(c.PostForm("count_"+row), 10, 32)
+		if err != nil {
+			ErrorHandler(c, fmt.Sprintf("Invalid count for '%s'", c.PostForm("name_"+row)), err)
+			return
+		}
+
+		imported.Tasks = append(imported.Tasks, deck.DeckTask{
+			Name:        strings.TrimSpace(c.PostForm("name_" + row)),
+			Weekly:      c.DefaultPostForm("weekly_"+row, "false") != "false",
+			Count:       uint32(count),
+			Description: strings.TrimSpace(c.PostForm("description_" + row)),
+		})
+	}
+
+	if rowErrors := deck.Validate(imported.Tasks); len(rowErrors) > 0 {
+		importErrorHandler(c, "the preview", rowErrors)
+		return
+	}
+
+	saveImport(c, minion, domain, merge, imported)
+}
+
+// saveImport merges the imported tasks into the domain, or makes a new domain of them
+func saveImport(c *gin.Context, minion Minion, domain Domain, merge bool, imported deck.Deck) {
+
+	if merge {
+		err := logic.MergeTasks(domain, imported.ToTasks())
+		if err != nil {
+			ErrorHandler(c, "Error importing tasks", err)
+			return
+		}
+
+		c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: strconv.Itoa(int(domain.ID))})
+		DomainEditHandler(c)
+		return
+	}
+
+	if imported.Name == "" {
+		imported.Name = "Unnamed Deck"
+	}
+	if utf8.RuneCountInString(imported.Name) > 200 {
+		ErrorHandler(c, "Decks need a name of at most 200 characters", nil)
+		return
+	}
+	if utf8.RuneCountInString(imported.Icon) > 8 {
+		ErrorHandler(c, "Icons are at most 8 characters", nil)
+		return
+	}
+	if imported.Colour != "" && !colourPattern.MatchString(imported.Colour) {
+		ErrorHandler(c, fmt.Sprintf("Invalid colour: '%s'", imported.Colour), nil)
+		return
+	}
+
+	domainID, err := db.CreateNewDomain(minion, imported.Name, imported.ToTasks())
+	if err != nil {
+		ErrorHandler(c, "Error creating new domain", err)
+		return
+	}
+
+	if imported.Description != "" || imported.Icon != "" || imported.Colour != "" {
+		domain, err = db.GetDomainByID(domainID)
+		if err == nil {
+			domain.Description = sql.NullString{String: imported.Description, Valid: imported.Description != ""}
+			domain.Icon, domain.Colour = imported.Icon, imported.Colour
+			err = db.DomainSetSettings(domain)
+		}
+		if err != nil {
+			ErrorHandler(c, "Error updating domain", err)
+			return
+		}
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: strconv.Itoa(int(domainID))})
+	DomainEditHandler(c)
+}
+
+// importErrorHandler lists everything that is wrong with an imported file, so it can be fixed in one go
+func importErrorHandler(c *gin.Context, filename string, rowErrors []deck.RowError) {
+
+	c.HTML(http.StatusBadRequest, "error.tmpl.html", gin.H{
+		"message": fmt.Sprintf("Nothing was imported, '%s' has problems", filename),
+		"errors":  rowErrors,
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -20,7 +22,9 @@ import (
 	"github.com/niven/taskmaster/config"
 	. "github.com/niven/taskmaster/data"
 	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/deck"
 	"github.com/niven/taskmaster/logic"
+	"github.com/niven/taskmaster/util"
 )
 
 var conf *oauth2.Config
@@ -52,7 +56,13 @@ func AuthorizeRequest() gin.HandlerFunc {
 		if isAuthorized(c) {
 			c.Next()
 		} else {
+			// remember where we were going, so following an invite link still works after logging in
+			if c.Request.Method == http.MethodGet {
+				session := sessions.Default(c)
+				session.Set("return-to", c.Request.URL.Path)
+			}
 			WelcomeHandler(c)
+			c.Abort()
 		}
 	}
 }
@@ -112,6 +122,9 @@ func AuthHandler(c *gin.Context) {
 		fmt.Println("error:", err)
 	}
 
+	returnTo, _ := session.Get("return-to").(string)
+	session.Delete("return-to")
+
 	session.Set("user-id", user.Email)
 	session.Set("user-name", user.Name)
 	err = session.Save()
@@ -120,6 +133,12 @@ func AuthHandler(c *gin.Context) {
 		return
 	}
 
+	// only ever redirect to our own pages
+	if strings.HasPrefix(returnTo, "/") && !strings.HasPrefix(returnTo, "//") {
+		c.Redirect(http.StatusFound, returnTo)
+		return
+	}
+
 	c.HTML(http.StatusOK, "index.tmpl.html", nil)
 
 }
@@ -132,7 +151,8 @@ func IndexHandler(c *gin.Context) {
 	}
 }
 
-func OverviewHandler(c *gin.Context) {
+// loadOrCreateMinion finds the Minion for the logged in user, creating one the first time they show up
+func loadOrCreateMinion(c *gin.Context) (Minion, error) {
 
 	session := sessions.Default(c)
 	userEmail := session.Get("user-id").(string)
@@ -147,34 +167,68 @@ func OverviewHandler(c *gin.Context) {
 		}
 		err := db.CreateMinion(userEmail, userName.(string))
 		if err != nil {
-			ErrorHandler(c, "", err)
-			return
+			return minion, err
 		}
 		db.LoadMinion(userEmail, &minion)
 	}
 
-	domains := db.GetDomainsForMinion(minion)
+	return minion, nil
+}
+
+func OverviewHandler(c *gin.Context) {
 
-	err := logic.Update(minion)
+	minion, err := loadOrCreateMinion(c)
 	if err != nil {
 		ErrorHandler(c, "", err)
 		return
 	}
 
+	err = logic.Update(minion)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
+	// after the update, which might have reshuffled a deck
+	domains := db.GetDomainsForMinion(minion)
+
 	// get all tasks for each domain: everything pending (for today/this week) & today's task
 	pendingTaskAssignments := db.AssignmentRetrieveForMinion(minion, false)
 
+	drafts, err := logic.DraftsWaitingFor(minion, domains)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
 	// split in Today, This Week, Overdue
-	now := time.Now()
-	today, this_week, overdue := logic.SplitTaskAssignments(pendingTaskAssignments, now)
+	now := time.Now().In(minion.Location())
+	today, this_week, overdue := logic.SplitTaskAssignments(pendingTaskAssignments, now, calendarsFor(minion, domains))
+
+	reshuffled := DomainFilter(domains, func(d Domain) bool { return d.ReshuffledOn(time.Now().In(d.Location())) })
+
+	// for showing the icon and colour of their deck on the cards
+	decks := make(map[uint32]Domain)
+	for _, d := range domains {
+		decks[d.ID] = d
+	}
+
+	// finished or skipped recently enough to take back
+	recent := TaskAssignmentFilter(db.AssignmentRetrieveForMinion(minion, true), func(ta TaskAssignment) bool {
+		return ta.CanUndo(minion, time.Now()) == nil
+	})
 
 	c.HTML(http.StatusOK, "index.tmpl.html", gin.H{
-		"minion":    minion,
-		"domains":   domains,
-		"pending":   today,
-		"this_week": this_week,
-		"overdue":   overdue,
-		"today":     now.Format("Monday January 2"),
+		"minion":     minion,
+		"domains":    domains,
+		"pending":    today,
+		"this_week":  this_week,
+		"overdue":    overdue,
+		"drafts":     drafts,
+		"reshuffled": reshuffled,
+		"recent":     recent,
+		"decks":      decks,
+		"today":      now.Format("Monday January 2"),
 	})
 
 }
@@ -193,72 +247,287 @@ func WelcomeHandler(c *gin.Context) {
 
 func SetupHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	renderSetup(c, minion, "")
+}
+
+// SetupTimezoneHandler sets the timezone your days start and end in
+func SetupTimezoneHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	timezone, valid := validTimezone(c.PostForm("timezone"))
+	if !valid {
+		ErrorHandler(c, fmt.Sprintf("Unknown timezone: '%s'", timezone), nil)
+		return
+	}
+
+	err := db.MinionSetTimezone(minion, timezone)
+	if err != nil {
+		ErrorHandler(c, "Error updating timezone", err)
+		return
+	}
+	minion.Timezone = timezone
+
+	renderSetup(c, minion, fmt.Sprintf("Your timezone is now %s.", timezone))
+}
+
+// SetupCalendarHandler sets the day your weeks start on and which days are your weekend
+func SetupCalendarHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
 		return
 	}
 
+	calendar, valid := readCalendar(c)
+	if !valid {
+		ErrorHandler(c, "Invalid calendar", nil)
+		return
+	}
+
+	err := db.MinionSetCalendar(minion, calendar)
+	if err != nil {
+		ErrorHandler(c, "Error updating calendar", err)
+		return
+	}
+	minion.WeekStart, minion.Weekend = calendar.WeekStart, calendar.Weekend
+
+	renderSetup(c, minion, fmt.Sprintf("Your weeks now start on %s.", calendar.WeekStart))
+}
+
+// readCalendar reads the week_start day and any number of weekend days from the form
+func readCalendar(c *gin.Context) (util.Calendar, bool) {
+
+	var calendar util.Calendar
+
+	weekStart, err := strconv.Atoi(c.PostForm("week_start"))
+	if err != nil || weekStart < 0 || weekStart > 6 {
+		return calendar, false
+	}
+	calendar.WeekStart = time.Weekday(weekStart)
+
+	for _, paramDay := range c.PostFormArray("weekend") {
+		day, err := strconv.Atoi(paramDay)
+		if err != nil || day < 0 || day > 6 {
+			return calendar, false
+		}
+		calendar.Weekend |= util.NewWeekdays(time.Weekday(day))
+	}
+
+	return calendar, true
+}
+
+// validTimezone checks the name is a timezone Go knows about, like Europe/Amsterdam
+func validTimezone(name string) (string, bool) {
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" {
+		return name, false
+	}
+
+	_, err := time.LoadLocation(name)
+	return name, err == nil
+}
+
+// renderSetup shows the setup page, with an optional notice at the top
+func renderSetup(c *gin.Context, minion Minion, notice string) {
+
 	domains := db.GetDomainsForMinion(minion)
+	for i := range domains {
+		members, err := db.GetMembersForDomain(domains[i])
+		if err != nil {
+			ErrorHandler(c, "", err)
+			return
+		}
+		domains[i].Members = members
+	}
+
+	away, err := db.GetAwayPeriods(minion)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
 
 	c.HTML(http.StatusOK, "setup.tmpl.html", gin.H{
-		"minion":  minion,
-		"domains": domains,
+		"minion":         minion,
+		"domains":        domains,
+		"away":           away,
+		"deck_templates": deck.Library,
+		"notice":         notice,
 	})
 }
 
+// SetupUndoHandler sets for how many minutes you can take back finishing a card, empty for the rest of the day
+func SetupUndoHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	var minutes sql.NullInt64
+	if paramMinutes := strings.TrimSpace(c.PostForm("minutes")); paramMinutes != "" {
+		n, err := strconv.Atoi(paramMinutes)
+		if err != nil || n < 0 {
+			ErrorHandler(c, "Invalid number of minutes", err)
+			return
+		}
+		minutes = sql.NullInt64{Int64: int64(n), Valid: true}
+	}
+
+	err := db.MinionSetUndoWindow(minion, minutes)
+	if err != nil {
+		ErrorHandler(c, "Error updating undo window", err)
+		return
+	}
+	minion.UndoMinutes = minutes
+
+	renderSetup(c, minion, "")
+}
+
 func TaskDoneHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
 		return
 	}
 
-	paramTaskAssignmentID, presentTaskAssignmentID := c.GetPostForm("task_assignment_id")
+	taskAssignmentID, presentTaskAssignmentID := postedAssignmentID(c)
 	paramReturnTask, presentReturnTask := c.GetPostForm("return_task")
 	if !presentTaskAssignmentID || !presentReturnTask {
-		ErrorHandler(c, "Missing parameters", nil)
 		return
 	}
 
-	taskAssignmentID, err := strconv.Atoi(paramTaskAssignmentID)
+	status := DoneAndStashed
+	if paramReturnTask == "true" {
+		status = DoneAndAvailable
+	}
+
+	_, err := logic.ChangeStatus(minion, taskAssignmentID, status)
 	if err != nil {
-		ErrorHandler(c, "Invalid task assignment ID", err)
+		statusError(c, err)
 		return
 	}
 
-	assignment := db.AssignmentRetrieve(int64(taskAssignmentID))
-	if assignment == nil {
-		ErrorHandler(c, "No such assignment", err)
+	c.JSON(http.StatusOK, nil)
+}
+
+// TaskSkipHandler puts one of your cards back in the deck without doing it, you don't get another one for that day
+func TaskSkipHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
 		return
 	}
 
-	if paramReturnTask == "true" {
-		assignment.Status = DoneAndAvailable
-	} else {
-		assignment.Status = DoneAndStashed
+	taskAssignmentID, present := postedAssignmentID(c)
+	if !present {
+		return
 	}
 
-	db.AssignmentUpdate(*assignment)
+	_, err := logic.ChangeStatus(minion, taskAssignmentID, Skipped)
+	if err != nil {
+		statusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// TaskSnoozeHandler moves one of your cards to a later day
+func TaskSnoozeHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	taskAssignmentID, present := postedAssignmentID(c)
+	if !present {
+		return
+	}
+
+	until, valid := parseDate(c.PostForm("until"))
+	if !valid {
+		ErrorHandler(c, "Invalid date", nil)
+		return
+	}
+
+	_, err := logic.Snooze(minion, taskAssignmentID, until)
+	if err != nil {
+		statusError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, nil)
 }
 
+// TaskUndoHandler takes back finishing or skipping one of your cards, for a little while after
+func TaskUndoHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	taskAssignmentID, present := postedAssignmentID(c)
+	if !present {
+		return
+	}
+
+	_, err := logic.Undo(minion, taskAssignmentID)
+	if err != nil {
+		statusError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/today")
+}
+
+// postedAssignmentID reads the task_assignment_id form field, rendering an error page if it's missing or not a number
+func postedAssignmentID(c *gin.Context) (uint32, bool) {
+
+	paramTaskAssignmentID, present := c.GetPostForm("task_assignment_id")
+	if !present {
+		ErrorHandler(c, "Missing parameters", nil)
+		return 0, false
+	}
+
+	taskAssignmentID, err := strconv.Atoi(paramTaskAssignmentID)
+	if err != nil || taskAssignmentID < 0 {
+		ErrorHandler(c, "Invalid task assignment ID", err)
+		return 0, false
+	}
+
+	return uint32(taskAssignmentID), true
+}
+
+// statusError renders what went wrong changing the status of a card. Someone else's cards don't exist
+// as far as the minion is concerned.
+func statusError(c *gin.Context, err error) {
+
+	switch err {
+	case ErrNotYourAssignment, logic.ErrNoSuchAssignment:
+		ErrorHandler(c, "No such assignment", nil)
+	case ErrInvalidTransition, ErrUndoExpired, logic.ErrSnoozeTooSoon, logic.ErrDrawnAgain:
+		ErrorHandler(c, err.Error(), nil)
+	default:
+		ErrorHandler(c, "Error updating assignment", err)
+	}
+}
+
 func TaskNewHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
 		return
 	}
 
@@ -274,14 +543,8 @@ func TaskNewHandler(c *gin.Context) {
 		ErrorHandler(c, "Invalid count", err)
 		return
 	}
-	domainID, err := strconv.Atoi(paramDomainID)
-	if err != nil {
-		ErrorHandler(c, fmt.Sprintf("Invalid Domain ID: '%s'", paramDomainID), err)
-		return
-	}
-	domain, err := db.GetDomainByID(uint32(domainID))
-	if err != nil || domain.Owner != minion.ID {
-		ErrorHandler(c, "Domain not found", err)
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
 		return
 	}
 
@@ -295,7 +558,7 @@ func TaskNewHandler(c *gin.Context) {
 
 	task := Task{
 		Name:     name,
-		DomainID: uint32(domainID),
+		DomainID: domain.ID,
 		Weekly:   weekly,
 		Count:    uint32(count),
 	}
@@ -313,54 +576,198 @@ func TaskNewHandler(c *gin.Context) {
 
 }
 
-func DomainNewHandler(c *gin.Context) {
+// TaskQuickAddHandler adds a pasted list of tasks, one per line, like "Clean fridge @weekly x2 #kitchen -- wipe
+// shelves too". It shows what the list turns into first, and only saves it when that is confirmed.
+func TaskQuickAddHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
 		return
 	}
 
-	domainName := c.DefaultPostForm("name", "Unnamed Deck")
-	db.CreateNewDomain(minion, domainName)
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	text, presentText := c.GetPostForm("text")
+	if !presentDomainID || !presentText {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
 
-	SetupHandler(c)
+	parsed, rowErrors := deck.ParseQuickAdd(text)
+
+	if c.PostForm("confirm") == "true" && len(rowErrors) == 0 && len(parsed) > 0 {
+		err := logic.AddTasks(domain, deck.Deck{Tasks: parsed}.ToTasks())
+		if err != nil {
+			ErrorHandler(c, "Error creating new tasks", err)
+			return
+		}
+
+		c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+		DomainEditHandler(c)
+		return
+	}
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "quickadd.tmpl.html", gin.H{
+		"minion":  minion,
+		"domains": domains,
+		"domain":  domain,
+		"text":    text,
+		"tasks":   parsed,
+		"errors":  rowErrors,
+	})
 }
 
-func DomainDeleteHandler(c *gin.Context) {
+// TaskUpdateHandler saves the deck editor: the name, count, description and weekly flag of every task in it,
+// and deletes the ones that are marked for it
+func TaskUpdateHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
 		return
 	}
 
-	domainID, err := strconv.Atoi(c.Param("domain_id"))
-	if err != nil || domainID < 0 {
-		ErrorHandler(c, "Invalid domain ID", err)
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
 		return
 	}
 
-	domain, err := db.GetDomainByID(uint32(domainID))
-	if err != nil || domain.Owner != minion.ID {
-		ErrorHandler(c, "Domain not found", err)
+	var edits []logic.TaskEdit
+	for _, paramTaskID := range c.PostFormArray("task_id") {
+
+		taskID, err := strconv.Atoi(paramTaskID)
+		if err != nil || taskID < 0 {
+			ErrorHandler(c, "Invalid task ID", err)
+			return
+		}
+
+		count, err := strconv.Atoi(c.PostForm("count_" + paramTaskID))
+		if err != nil || count < 0 {
+			ErrorHandler(c, "Invalid count", err)
+			return
+		}
+
+		name := strings.TrimSpace(c.PostForm("name_" + paramTaskID))
+		if name == "" {
+			ErrorHandler(c, "Tasks need a name", nil)
+			return
+		}
+
+		description := strings.TrimSpace(c.PostForm("description_" + paramTaskID))
+
+		edits = append(edits, logic.TaskEdit{
+			Task: Task{
+				ID:          uint32(taskID),
+				DomainID:    domain.ID,
+				Name:        name,
+				Weekly:      c.PostForm("weekly_"+paramTaskID) == "true",
+				Count:       uint32(count),
+				Description: sql.NullString{String: description, Valid: description != ""},
+			},
+			Delete: c.PostForm("delete_"+paramTaskID) == "true",
+		})
+	}
+
+	err := logic.UpdateTasks(domain, edits)
+	if err == logic.ErrNoSuchTask {
+		ErrorHandler(c, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		ErrorHandler(c, "Error updating tasks", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
+
+func DomainNewHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domainName := strings.TrimSpace(c.PostForm("name"))
+
+	// optionally start from one of the ready-made decks, with enough cards for everyone who'll share it
+	var tasks []Task
+	if paramTemplate := c.PostForm("template"); paramTemplate != "" {
+		template, found := deck.FindTemplate(paramTemplate)
+		if !found {
+			ErrorHandler(c, fmt.Sprintf("Unknown deck template: '%s'", paramTemplate), nil)
+			return
+		}
+
+		members, err := strconv.Atoi(c.DefaultPostForm("members", "1"))
+		if err != nil || members < 1 || members > 99 {
+			ErrorHandler(c, "Invalid number of members", err)
+			return
+		}
+
+		tasks = template.TasksFor(members)
+		if domainName == "" {
+			domainName = template.Name
+		}
+	}
+
+	if domainName == "" {
+		domainName = "Unnamed Deck"
+	}
+
+	_, err := db.CreateNewDomain(minion, domainName, tasks)
+	if err != nil {
+		ErrorHandler(c, "Error creating new domain", err)
+		return
+	}
+
+	SetupHandler(c)
+}
+
+func DomainDeleteHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ManageDomain)
+	if !allowed {
 		return
 	}
 
 	db.DomainDelete(domain)
 
-	domains := db.GetDomainsForMinion(minion)
+	SetupHandler(c)
+}
 
-	c.HTML(http.StatusOK, "setup.tmpl.html", gin.H{
-		"minion":  minion,
-		"domains": domains,
-	})
+// calendarsFor looks up the calendar of each domain as the minion sees it, for deciding when cards are due
+func calendarsFor(minion Minion, domains []Domain) func(domainID uint32) util.Calendar {
+
+	calendars := make(map[uint32]util.Calendar)
+	for _, d := range domains {
+		calendars[d.ID] = d.CalendarFor(minion)
+	}
+
+	return func(domainID uint32) util.Calendar {
+		if calendar, found := calendars[domainID]; found {
+			return calendar
+		}
+		return minion.Calendar()
+	}
 }
 
 func ErrorHandler(c *gin.Context, message string, err error) {
@@ -374,23 +781,13 @@ func ErrorHandler(c *gin.Context, message string, err error) {
 
 func DomainEditHandler(c *gin.Context) {
 
-	session := sessions.Default(c)
-	userEmail := session.Get("user-id").(string)
-	var minion Minion
-	found := db.LoadMinion(userEmail, &minion)
+	minion, found := currentMinion(c)
 	if !found {
-		ErrorHandler(c, "User authenticated but not found", nil)
-	}
-
-	domainID, err := strconv.Atoi(c.Param("domain_id"))
-	if err != nil || domainID < 0 {
-		ErrorHandler(c, "Invalid domain ID", err)
 		return
 	}
 
-	domain, err := db.GetDomainByID(uint32(domainID))
-	if err != nil || domain.Owner != minion.ID {
-		ErrorHandler(c, "Domain not found", err)
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
 		return
 	}
 
@@ -400,15 +797,279 @@ func DomainEditHandler(c *gin.Context) {
 		ErrorHandler(c, "Domain not found", err)
 		return
 	}
+	domain.Members, err = db.GetMembersForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return
+	}
+
+	var invites []DomainInvite
+	var joinRequests []JoinRequest
+	if domain.Role.Can(ManageMembers) {
+		invites, err = db.GetInvitesForDomain(domain)
+		if err != nil {
+			ErrorHandler(c, "", err)
+			return
+		}
+		joinRequests, err = db.GetJoinRequestsForDomain(domain)
+		if err != nil {
+			ErrorHandler(c, "", err)
+			return
+		}
+	}
+
 	// setup menu needs the list
 	domains := db.GetDomainsForMinion(minion)
 
 	c.HTML(http.StatusOK, "domain.tmpl.html", gin.H{
-		"minion":  minion,
-		"domain":  domain,
-		"domains": domains,
-		"daily":   TaskFilter(tasks, func(t Task) bool { return !t.Weekly }),
-		"weekly":  TaskFilter(tasks, func(t Task) bool { return t.Weekly }),
+		"minion":           minion,
+		"domain":           domain,
+		"domains":          domains,
+		"canEditTasks":     domain.Role.Can(EditTasks),
+		"canManageMembers": domain.Role.Can(ManageMembers),
+		"canManageDomain":  domain.Role.Can(ManageDomain),
+		"roles":            AssignableRoles,
+		"invites":          invites,
+		"join_requests":    joinRequests,
+		"base_url":         config.EnvironmentVars["BASE_URL"],
+		"daily":            TaskFilter(tasks, func(t Task) bool { return !t.Weekly }),
+		"weekly":           TaskFilter(tasks, func(t Task) bool { return t.Weekly }),
 	})
 
 }
+
+// DomainModeHandler switches between the ways of handing out cards: at random, rotating, drafting or auctioning them
+func DomainModeHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramMode, presentMode := c.GetPostForm("mode")
+	if !presentDomainID || !presentMode {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	mode := AssignmentMode(paramMode)
+	if !mode.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Invalid mode: '%s'", paramMode), nil)
+		return
+	}
+
+	err := db.DomainSetAssignmentMode(domain, mode)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainOverdueHandler sets what happens to cards that stay overdue
+func DomainOverdueHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramPolicy, presentPolicy := c.GetPostForm("policy")
+	if !presentDomainID || !presentPolicy {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	policy := OverduePolicy(paramPolicy)
+	if !policy.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Invalid policy: '%s'", paramPolicy), nil)
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultPostForm("days", "3"))
+	if err != nil || days < 1 {
+		ErrorHandler(c, "Invalid number of days", err)
+		return
+	}
+
+	err = db.DomainSetOverduePolicy(domain, policy, uint32(days))
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainResetHandler sets how often the deck is shuffled back together and whether stashed cards stay out of it
+func DomainResetHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramCadence, presentCadence := c.GetPostForm("cadence")
+	if !presentDomainID || !presentCadence {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	cadence := ResetCadence(paramCadence)
+	if !cadence.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Invalid reset cadence: '%s'", paramCadence), nil)
+		return
+	}
+
+	day, err := strconv.Atoi(c.DefaultPostForm("day", "1"))
+	if err != nil || day < 1 || day > 31 {
+		ErrorHandler(c, "Invalid day of the month", err)
+		return
+	}
+
+	carryStash := c.DefaultPostForm("carry_stash", "false") != "false"
+
+	err = db.DomainSetResetCadence(domain, cadence, uint32(day), carryStash)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainExhaustionHandler sets what happens when someone has to draw from an empty deck
+func DomainExhaustionHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramPolicy, presentPolicy := c.GetPostForm("policy")
+	if !presentDomainID || !presentPolicy {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageDomain)
+	if !allowed {
+		return
+	}
+
+	policy := ExhaustionPolicy(paramPolicy)
+	if !policy.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Invalid policy: '%s'", paramPolicy), nil)
+		return
+	}
+
+	err := db.DomainSetExhaustionPolicy(domain, policy)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainTimezoneHandler gives a domain its own timezone, or makes it follow the owner's when left empty
+func DomainTimezoneHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageDomain)
+	if !allowed {
+		return
+	}
+
+	var timezone sql.NullString
+	if strings.TrimSpace(c.PostForm("timezone")) != "" {
+		name, valid := validTimezone(c.PostForm("timezone"))
+		if !valid {
+			ErrorHandler(c, fmt.Sprintf("Unknown timezone: '%s'", name), nil)
+			return
+		}
+		timezone = sql.NullString{String: name, Valid: true}
+	}
+
+	err := db.DomainSetTimezone(domain, timezone)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainCalendarHandler gives a domain its own week start and weekend, or makes it follow the owner's
+func DomainCalendarHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageDomain)
+	if !allowed {
+		return
+	}
+
+	var own *util.Calendar
+	if c.DefaultPostForm("own", "false") != "false" {
+		calendar, valid := readCalendar(c)
+		if !valid {
+			ErrorHandler(c, "Invalid calendar", nil)
+			return
+		}
+		own = &calendar
+	}
+
+	err := db.DomainSetCalendar(domain, own)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
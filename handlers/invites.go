@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+// no 0/O or 1/I so codes can be read out loud or copied from a phone
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func randJoinCode() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	for i := range b {
+		b[i] = joinCodeAlphabet[int(b[i])%len(joinCodeAlphabet)]
+	}
+	return string(b)
+}
+
+// DomainInviteNewHandler creates an invite for a domain that can be used a number of times within a number of days
+func DomainInviteNewHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageMembers)
+	if !allowed {
+		return
+	}
+
+	uses, err := strconv.Atoi(c.DefaultPostForm("uses", "1"))
+	if err != nil || uses < 1 {
+		ErrorHandler(c, "Invalid number of uses", err)
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultPostForm("days", "7"))
+	if err != nil || days < 1 {
+		ErrorHandler(c, "Invalid number of days", err)
+		return
+	}
+
+	invite := DomainInvite{
+		DomainID:  domain.ID,
+		Token:     randToken(),
+		Code:      randJoinCode(),
+		CreatedBy: minion.ID,
+		ExpiresOn: time.Now().AddDate(0, 0, days),
+		UsesLeft:  uint32(uses),
+	}
+
+	err = db.InviteCreate(invite)
+	if err != nil {
+		ErrorHandler(c, "Error creating invite", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
+
+func DomainInviteRevokeHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ManageMembers)
+	if !allowed {
+		return
+	}
+
+	inviteID, err := strconv.Atoi(c.Param("invite_id"))
+	if err != nil || inviteID < 0 {
+		ErrorHandler(c, "Invalid invite ID", err)
+		return
+	}
+
+	err = db.InviteRevoke(domain, uint32(inviteID))
+	if err != nil {
+		ErrorHandler(c, "Error revoking invite", err)
+		return
+	}
+
+	DomainEditHandler(c)
+}
+
+// DomainJoinHandler is where invite links point to
+func DomainJoinHandler(c *gin.Context) {
+	joinDomain(c, c.Param("token"))
+}
+
+// DomainJoinCodeHandler is for joining by typing in the short code of an invite
+func DomainJoinCodeHandler(c *gin.Context) {
+
+	code, present := c.GetPostForm("code")
+	if !present || code == "" {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	joinDomain(c, code)
+}
+
+func joinDomain(c *gin.Context, tokenOrCode string) {
+
+	// the link might be the first thing someone sees after logging in
+	minion, err := loadOrCreateMinion(c)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
+	domain, waiting, err := db.InviteRedeem(tokenOrCode, minion)
+	if err == db.ErrInviteInvalid {
+		ErrorHandler(c, "This invite has expired or has already been used", nil)
+		return
+	}
+	if err != nil {
+		ErrorHandler(c, "Error joining domain", err)
+		return
+	}
+
+	if waiting {
+		renderSetup(c, minion, fmt.Sprintf("You asked to join %s, the owner still has to approve that.", domain.Name))
+		return
+	}
+
+	renderSetup(c, minion, fmt.Sprintf("You are now a member of %s!", domain.Name))
+}
+
+// DomainJoinRequestHandler approves or declines someone who asked to join
+func DomainJoinRequestHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	decision := c.Param("decision")
+	if decision != "approve" && decision != "decline" {
+		ErrorHandler(c, "Invalid decision", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ManageMembers)
+	if !allowed {
+		return
+	}
+
+	requesterID, err := strconv.Atoi(c.Param("minion_id"))
+	if err != nil || requesterID < 0 {
+		ErrorHandler(c, "Invalid member ID", err)
+		return
+	}
+
+	err = db.JoinRequestResolve(domain, Minion{ID: uint32(requesterID)}, decision == "approve")
+	if err != nil {
+		ErrorHandler(c, "No such join request", err)
+		return
+	}
+
+	DomainEditHandler(c)
+}
+
+// DomainApprovalHandler sets whether people joining through an invite need approval
+func DomainApprovalHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageMembers)
+	if !allowed {
+		return
+	}
+
+	requireApproval := c.DefaultPostForm("require_approval", "false") != "false"
+
+	err := db.DomainSetRequireApproval(domain, requireApproval)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/util"
+)
+
+// MarketHandler shows the cards on offer in a domain, and lets you offer your own
+func MarketHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	var err error
+	domain.Members, err = db.GetMembersForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return
+	}
+	pending, err := db.GetPendingAssignmentsForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+	offers, err := db.GetOffersForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+	moves, err := db.GetMovesForDomain(domain, 20)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
+	points := 0
+	names := make(map[int64]string)
+	for _, member := range domain.Members {
+		names[int64(member.ID)] = member.Name
+		if member.ID == minion.ID {
+			points = member.Points
+		}
+	}
+
+	// overdue cards can't be offered anymore
+	today := util.StrDateFromTime(time.Now().In(domain.Location()))
+	pending = TaskAssignmentFilter(pending, func(ta TaskAssignment) bool { return util.StrDateFromTime(ta.DueDate(domain.Calendar())) >= today })
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "market.tmpl.html", gin.H{
+		"minion":   minion,
+		"domain":   domain,
+		"domains":  domains,
+		"points":   points,
+		"names":    names,
+		"offers":   offers,
+		"moves":    moves,
+		"mine":     TaskAssignmentFilter(pending, func(ta TaskAssignment) bool { return ta.MinionID.Int64 == int64(minion.ID) }),
+		"theirs":   TaskAssignmentFilter(pending, func(ta TaskAssignment) bool { return ta.MinionID.Int64 != int64(minion.ID) }),
+		"canTrade": domain.Role.Can(DrawCards),
+	})
+}
+
+// OfferNewHandler offers one of your cards to a housemate in exchange for one of theirs, or to anyone for a bounty
+func OfferNewHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramAssignmentID, presentAssignmentID := c.GetPostForm("task_assignment_id")
+	if !presentDomainID || !presentAssignmentID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, DrawCards)
+	if !allowed {
+		return
+	}
+
+	pending, err := db.GetPendingAssignmentsForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "", err)
+		return
+	}
+
+	assignment, found := findAssignment(pending, paramAssignmentID)
+	if !found || assignment.MinionID.Int64 != int64(minion.ID) {
+		ErrorHandler(c, "No such assignment", nil)
+		return
+	}
+	if util.StrDateFromTime(assignment.DueDate(domain.Calendar())) < util.StrDateFromTime(time.Now().In(domain.Location())) {
+		ErrorHandler(c, "This card is overdue, it can't be offered anymore", nil)
+		return
+	}
+
+	offer := Offer{
+		DomainID:   domain.ID,
+		Assignment: assignment,
+		OfferedBy:  minion,
+		ExpiresOn:  assignment.DueDate(domain.Calendar()),
+	}
+
+	if c.DefaultPostForm("kind", "bounty") == "swap" {
+		swapFor, found := findAssignment(pending, c.PostForm("swap_for"))
+		if !found || !swapFor.MinionID.Valid || swapFor.MinionID.Int64 == int64(minion.ID) {
+			ErrorHandler(c, "No such assignment to swap for", nil)
+			return
+		}
+		offer.SwapFor = swapFor
+		offer.OfferedTo = swapFor.MinionID
+	} else {
+		bounty, err := strconv.Atoi(c.DefaultPostForm("bounty", "0"))
+		if err != nil || bounty < 0 {
+			ErrorHandler(c, "Invalid bounty", err)
+			return
+		}
+		points, err := db.GetPoints(domain, minion)
+		if err != nil {
+			ErrorHandler(c, "", err)
+			return
+		}
+		if bounty > points {
+			ErrorHandler(c, "You don't have enough points for that bounty", nil)
+			return
+		}
+		offer.Bounty = bounty
+	}
+
+	err = db.OfferCreate(offer)
+	if err != nil {
+		ErrorHandler(c, "Error creating offer", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	MarketHandler(c)
+}
+
+// OfferAcceptHandler takes over an offered card
+func OfferAcceptHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), DrawCards)
+	if !allowed {
+		return
+	}
+
+	offerID, err := strconv.Atoi(c.Param("offer_id"))
+	if err != nil || offerID < 0 {
+		ErrorHandler(c, "Invalid offer ID", err)
+		return
+	}
+
+	err = db.OfferAccept(domain, uint32(offerID), minion)
+	if err == db.ErrOfferInvalid || err == db.ErrBountyUnpaid {
+		ErrorHandler(c, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		ErrorHandler(c, "Error accepting offer", err)
+		return
+	}
+
+	MarketHandler(c)
+}
+
+// OfferCancelHandler withdraws your own offer, or declines a swap someone offered you
+func OfferCancelHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	offerID, err := strconv.Atoi(c.Param("offer_id"))
+	if err != nil || offerID < 0 {
+		ErrorHandler(c, "Invalid offer ID", err)
+		return
+	}
+
+	err = db.OfferCancel(domain, uint32(offerID), minion)
+	if err != nil {
+		ErrorHandler(c, "No such offer", err)
+		return
+	}
+
+	MarketHandler(c)
+}
+
+// findAssignment looks up an assignment by the ID from a form
+func findAssignment(assignments []TaskAssignment, paramAssignmentID string) (TaskAssignment, bool) {
+
+	assignmentID, err := strconv.Atoi(paramAssignmentID)
+	if err != nil || assignmentID < 0 {
+		return TaskAssignment{}, false
+	}
+
+	for _, assignment := range assignments {
+		if assignment.ID == uint32(assignmentID) {
+			return assignment, true
+		}
+	}
+
+	return TaskAssignment{}, false
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+// DomainMemberAddHandler adds someone to a domain by email. That person has to have logged in at least once.
+func DomainMemberAddHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramEmail, presentEmail := c.GetPostForm("email")
+	if !presentDomainID || !presentEmail {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageMembers)
+	if !allowed {
+		return
+	}
+
+	role := Role(c.DefaultPostForm("role", string(Member)))
+	if !role.IsValid() || !domain.Role.Outranks(role) {
+		ErrorHandler(c, "You can't hand out that role", nil)
+		return
+	}
+
+	var member Minion
+	if !db.LoadMinion(paramEmail, &member) {
+		ErrorHandler(c, fmt.Sprintf("Nobody with email '%s' has logged in to Task Master yet", paramEmail), nil)
+		return
+	}
+
+	err := db.DomainAddMember(domain, member, role)
+	if err != nil {
+		ErrorHandler(c, "Error adding member", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
+
+// DomainMemberRemoveHandler removes someone from a domain. Their pending cards go back into the deck.
+func DomainMemberRemoveHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ManageMembers)
+	if !allowed {
+		return
+	}
+
+	member, isMember := loadMember(c, domain, c.Param("minion_id"))
+	if !isMember {
+		return
+	}
+
+	if !domain.Role.Outranks(member.Role) {
+		ErrorHandler(c, "You can't remove that member", nil)
+		return
+	}
+
+	err := db.DomainRemoveMember(domain, member.Minion)
+	if err != nil {
+		ErrorHandler(c, "Error removing member", err)
+		return
+	}
+
+	DomainEditHandler(c)
+}
+
+// DomainMemberRoleHandler changes the role of a member to one below your own
+func DomainMemberRoleHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramMinionID, presentMinionID := c.GetPostForm("minion_id")
+	paramRole, presentRole := c.GetPostForm("role")
+	if !presentDomainID || !presentMinionID || !presentRole {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageMembers)
+	if !allowed {
+		return
+	}
+
+	member, isMember := loadMember(c, domain, paramMinionID)
+	if !isMember {
+		return
+	}
+
+	role := Role(paramRole)
+	if !role.IsValid() || !domain.Role.Outranks(role) || !domain.Role.Outranks(member.Role) {
+		ErrorHandler(c, "You can't hand out that role", nil)
+		return
+	}
+
+	err := db.DomainSetMemberRole(domain, member.Minion, role)
+	if err != nil {
+		ErrorHandler(c, "Error changing role", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
+
+// DomainTransferHandler hands the domain over to another member
+func DomainTransferHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramMinionID, presentMinionID := c.GetPostForm("minion_id")
+	if !presentDomainID || !presentMinionID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageDomain)
+	if !allowed {
+		return
+	}
+
+	member, isMember := loadMember(c, domain, paramMinionID)
+	if !isMember {
+		return
+	}
+	if member.ID == minion.ID {
+		ErrorHandler(c, "You already own this domain", nil)
+		return
+	}
+
+	err := db.DomainTransferOwnership(domain, member.Minion)
+	if err != nil {
+		ErrorHandler(c, "Error transferring ownership", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainEditHandler(c)
+}
+
+// DomainLeaveHandler removes yourself from a domain. If you are the owner someone else takes over.
+func DomainLeaveHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), ViewBoard)
+	if !allowed {
+		return
+	}
+
+	err := db.DomainRemoveMember(domain, minion)
+	if err != nil {
+		ErrorHandler(c, "Error leaving domain", err)
+		return
+	}
+
+	renderSetup(c, minion, fmt.Sprintf("You left %s.", domain.Name))
+}
+
+// loadMember finds a member of the domain, rendering an error page if they aren't one
+func loadMember(c *gin.Context, domain Domain, paramMinionID string) (DomainMember, bool) {
+
+	var member DomainMember
+
+	minionID, err := strconv.Atoi(paramMinionID)
+	if err != nil || minionID < 0 {
+		ErrorHandler(c, "Invalid member ID", err)
+		return member, false
+	}
+
+	member.ID = uint32(minionID)
+	role, isMember := db.GetMembership(domain, member.Minion)
+	if !isMember {
+		ErrorHandler(c, "No such member", nil)
+		return member, false
+	}
+	member.Role = role
+
+	return member, true
+}
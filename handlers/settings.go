@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/niven/taskmaster/config"
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+var colourPattern = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+// DomainSettingsHandler shows everything about a deck that can be changed, apart from its tasks and members
+func DomainSettingsHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, c.Param("domain_id"), EditTasks)
+	if !allowed {
+		return
+	}
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "domain_settings.tmpl.html", gin.H{
+		"minion":           minion,
+		"domain":           domain,
+		"domains":          domains,
+		"canManageDomain":  domain.Role.Can(ManageDomain),
+		"modes":            AssignmentModes,
+		"overdue_policies": OverduePolicies,
+		"reset_cadences":   ResetCadences,
+		"exhaustion":       ExhaustionPolicies,
+		"visibilities":     Visibilities,
+		"max_draws":        MaxDrawsPerDay,
+		"canManageMembers": domain.Role.Can(ManageMembers),
+		"base_url":         config.EnvironmentVars["BASE_URL"],
+	})
+}
+
+// DomainSettingsSaveHandler renames a deck and changes its description, looks and how many cards it hands out
+func DomainSettingsSaveHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	domain.Name = strings.TrimSpace(c.PostForm("name"))
+	if domain.Name == "" || utf8.RuneCountInString(domain.Name) > 200 {
+		ErrorHandler(c, "Decks need a name of at most 200 characters", nil)
+		return
+	}
+
+	description := strings.TrimSpace(c.PostForm("description"))
+	domain.Description = sql.NullString{String: description, Valid: description != ""}
+
+	domain.Icon = strings.TrimSpace(c.PostForm("icon"))
+	if utf8.RuneCountInString(domain.Icon) > 8 {
+		ErrorHandler(c, "Icons are at most 8 characters", nil)
+		return
+	}
+
+	domain.Colour = strings.TrimSpace(c.PostForm("colour"))
+	if domain.Colour != "" && !colourPattern.MatchString(domain.Colour) {
+		ErrorHandler(c, fmt.Sprintf("Invalid colour: '%s'", domain.Colour), nil)
+		return
+	}
+
+	draws, err := strconv.Atoi(c.DefaultPostForm("draws_per_day", "1"))
+	if err != nil || draws < 1 || draws > MaxDrawsPerDay {
+		ErrorHandler(c, "Invalid number of cards per day", err)
+		return
+	}
+	domain.DrawsPerDay = uint32(draws)
+
+	err = db.DomainSetSettings(domain)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainVisibilityHandler sets whether only members can look at a deck, or anyone who has its share link.
+// Making it private and public again gets it a new link.
+func DomainVisibilityHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	paramVisibility, presentVisibility := c.GetPostForm("visibility")
+	if !presentDomainID || !presentVisibility {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, ManageDomain)
+	if !allowed {
+		return
+	}
+
+	visibility := Visibility(paramVisibility)
+	if !visibility.IsValid() {
+		ErrorHandler(c, fmt.Sprintf("Invalid visibility: '%s'", paramVisibility), nil)
+		return
+	}
+
+	// the link stays the same while the deck stays public
+	shareToken := domain.ShareToken
+	if visibility == PublicDomain && !shareToken.Valid {
+		shareToken = sql.NullString{String: randToken(), Valid: true}
+	}
+	if visibility == PrivateDomain {
+		shareToken = sql.NullString{}
+	}
+
+	err := db.DomainSetVisibility(domain, visibility, shareToken)
+	if err != nil {
+		ErrorHandler(c, "Error updating domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: paramDomainID})
+	DomainSettingsHandler(c)
+}
+
+// DomainSharedHandler shows a public deck to whoever has its share link: what it looks like and its tasks,
+// but nothing about who is in it or what they drew
+func DomainSharedHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	domain, err := db.GetSharedDomain(c.Param("token"))
+	if err != nil {
+		ErrorHandler(c, "Domain not found", nil)
+		return
+	}
+
+	tasks, err := db.GetTasksForDomain(domain)
+	if err != nil {
+		ErrorHandler(c, "Domain not found", err)
+		return
+	}
+
+	// setup menu needs the list
+	domains := db.GetDomainsForMinion(minion)
+
+	c.HTML(http.StatusOK, "domain_shared.tmpl.html", gin.H{
+		"minion":  minion,
+		"domains": domains,
+		"name":    domain.Name,
+		"about":   domain.Description.String,
+		"icon":    domain.Icon,
+		"colour":  domain.Colour,
+		"daily":   TaskFilter(tasks, func(t Task) bool { return !t.Weekly }),
+		"weekly":  TaskFilter(tasks, func(t Task) bool { return t.Weekly }),
+	})
+}
+
+// DomainCloneHandler copies a deck and its tasks into a new one owned by you, optionally with the same members.
+// Nothing that was drawn comes along.
+func DomainCloneHandler(c *gin.Context) {
+
+	minion, found := currentMinion(c)
+	if !found {
+		return
+	}
+
+	paramDomainID, presentDomainID := c.GetPostForm("domain_id")
+	if !presentDomainID {
+		ErrorHandler(c, "Missing parameters", nil)
+		return
+	}
+
+	domain, allowed := authorizeDomain(c, minion, paramDomainID, EditTasks)
+	if !allowed {
+		return
+	}
+
+	withMembers := c.DefaultPostForm("members", "false") != "false"
+	if withMembers && !domain.Role.Can(ManageMembers) {
+		ErrorHandler(c, "You are not allowed to do that", nil)
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = "Copy of " + domain.Name
+	}
+	if utf8.RuneCountInString(name) > 200 {
+		ErrorHandler(c, "Decks need a name of at most 200 characters", nil)
+		return
+	}
+
+	cloneID, err := db.DomainClone(domain, minion, name, withMembers)
+	if err != nil {
+		ErrorHandler(c, "Error copying domain", err)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "domain_id", Value: strconv.Itoa(int(cloneID))})
+	DomainEditHandler(c)
+}
@@ -0,0 +1,293 @@
+package logic
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+var (
+	ErrNotEnoughPoints = errors.New("You don't have enough points for that bid")
+	ErrNoSuchTask      = errors.New("No such task")
+)
+
+// PlaceBid saves a bid for the next auction. All bids of a member together can't be for more points than they have.
+func PlaceBid(minion Minion, domain Domain, bid Bid) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		tasks, err := dtx.Tasks()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, task := range tasks {
+			found = found || task.ID == bid.TaskID
+		}
+		if !found {
+			return ErrNoSuchTask
+		}
+
+		members, err := dtx.Members()
+		if err != nil {
+			return err
+		}
+		balance := 0
+		for _, member := range members {
+			if member.ID == minion.ID {
+				balance = member.Points
+			}
+		}
+
+		bids, err := dtx.Bids()
+		if err != nil {
+			return err
+		}
+		total := bid.Amount()
+		for _, other := range bids {
+			if other.MinionID == minion.ID && other.TaskID != bid.TaskID {
+				total += other.Amount()
+			}
+		}
+		if total > balance {
+			return ErrNotEnoughPoints
+		}
+
+		bid.DomainID = domain.ID
+		bid.MinionID = minion.ID
+		return dtx.BidSet(bid)
+	})
+}
+
+// HoldAuction hands out the cards for the current period now instead of waiting for the next reset
+func HoldAuction(domain Domain) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+		return holdAuction(dtx)
+	})
+}
+
+// holdAuction divides all cards in the deck between the members, as evenly as possible, in the way
+// that makes everyone the least unhappy according to their bids. Then everyone pays for the bids that
+// worked out and a new round of bidding starts.
+func holdAuction(dtx *db.DomainTx) error {
+
+	held, err := dtx.HasAllocations()
+	if err != nil || held {
+		return err
+	}
+
+	members, err := dtx.Members()
+	if err != nil {
+		return err
+	}
+
+	// viewers don't do chores, so they don't take part
+	var bidders []Minion
+	for _, member := range members {
+		if member.Role.Can(DrawCards) {
+			bidders = append(bidders, member.Minion)
+		}
+	}
+
+	// copies still in someone's hand from before the reset stay theirs
+	available, err := dtx.AvailableTasks()
+	if err != nil {
+		return err
+	}
+
+	undrawn, err := dtx.UndrawnAllocations()
+	if err != nil {
+		return err
+	}
+
+	cards := auctionCards(available, undrawn)
+	if len(bidders) == 0 || len(cards) == 0 {
+		return nil
+	}
+
+	// so ties don't always work out the same way
+	rand.Shuffle(len(cards), func(i, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+
+	bids, err := dtx.Bids()
+	if err != nil {
+		return err
+	}
+
+	owners := allocateCards(bidders, cards, bids)
+	for i, card := range cards {
+		err = dtx.AllocationInsert(bidders[owners[i]], card)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, bidder := range bidders {
+		paid := auctionPayment(bidder, cards, bidders, owners, bids)
+		if paid > 0 {
+			err = dtx.SpendPoints(bidder, paid)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return dtx.ClearBids()
+}
+
+// auctionCards lays out the copies that are in the deck and nobody claimed yet, the same pool a draft picks from
+func auctionCards(available []Task, undrawn map[uint32]uint32) []Task {
+	return deckCards(remainingCards(available, undrawn))
+}
+
+// allocateCards returns for every card the index of the member that gets it. Everyone gets the same
+// number of cards give or take one, and the sum of the bids of members getting cards they bid on is as
+// low as possible: avoiding a card costs points, claiming one earns them.
+func allocateCards(members []Minion, cards []Task, bids []Bid) []int {
+
+	bidPoints := make(map[[2]uint32]int)
+	maxAmount := 0
+	for _, bid := range bids {
+		bidPoints[[2]uint32{bid.MinionID, bid.TaskID}] = bid.Points
+		if bid.Amount() > maxAmount {
+			maxAmount = bid.Amount()
+		}
+	}
+
+	// every member gets a number of slots for cards, the last one is an extra one when the cards
+	// don't divide evenly. Those are filled up with 'no card' so nobody ends up with two less.
+	perMember := (len(cards) + len(members) - 1) / len(members)
+	uneven := len(cards)%len(members) != 0
+	size := perMember * len(members)
+
+	// more than all bids could ever add up to, to keep 'no card' out of the regular slots
+	forbidden := 2*maxAmount*len(cards) + 1
+
+	cost := make([][]int, size)
+	for row := range cost {
+		cost[row] = make([]int, size)
+		for slot := range cost[row] {
+			member := slot / perMember
+			extra := uneven && slot%perMember == perMember-1
+
+			switch {
+			case row < len(cards):
+				cost[row][slot] = bidPoints[[2]uint32{members[member].ID, cards[row].ID}]
+			case !extra:
+				cost[row][slot] = forbidden
+			}
+		}
+	}
+
+	slots := minCostAssignment(cost)
+
+	result := make([]int, len(cards))
+	for i := range cards {
+		result[i] = slots[i] / perMember
+	}
+
+	return result
+}
+
+// auctionPayment is what the member pays after the auction: for every avoid bid on a task they didn't
+// get and every claim bid on a task they did get
+func auctionPayment(member Minion, cards []Task, members []Minion, owners []int, bids []Bid) int {
+
+	got := make(map[uint32]bool)
+	for i, card := range cards {
+		if members[owners[i]].ID == member.ID {
+			got[card.ID] = true
+		}
+	}
+
+	paid := 0
+	for _, bid := range bids {
+		if bid.MinionID != member.ID {
+			continue
+		}
+		if (bid.Avoid() && !got[bid.TaskID]) || (bid.Claim() && got[bid.TaskID]) {
+			paid += bid.Amount()
+		}
+	}
+
+	return paid
+}
+
+// minCostAssignment solves the assignment problem for a square cost matrix with the Hungarian algorithm.
+// Returns the column picked for every row, so that the sum of the costs is as low as possible.
+// Relevant reading: https://en.wikipedia.org/wiki/Hungarian_algorithm
+func minCostAssignment(cost [][]int) []int {
+
+	n := len(cost)
+
+	// potentials for rows and columns, and the row matched to each column (all 1 based, 0 is a sentinel)
+	u := make([]int, n+1)
+	v := make([]int, n+1)
+	match := make([]int, n+1)
+	way := make([]int, n+1)
+
+	for row := 1; row <= n; row++ {
+
+		match[0] = row
+		col := 0
+		minv := make([]int, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.MaxInt64
+		}
+
+		// grow an alternating path until it reaches a free column
+		for match[col] != 0 {
+			used[col] = true
+			current := match[col]
+			delta := math.MaxInt64
+			next := 0
+
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				reduced := cost[current-1][j-1] - u[current] - v[j]
+				if reduced < minv[j] {
+					minv[j] = reduced
+					way[j] = col
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					next = j
+				}
+			}
+
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			col = next
+		}
+
+		// flip the path
+		for col != 0 {
+			prev := way[col]
+			match[col] = match[prev]
+			col = prev
+		}
+	}
+
+	result := make([]int, n)
+	for j := 1; j <= n; j++ {
+		if match[j] != 0 {
+			result[match[j]-1] = j - 1
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"testing"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+func TestMinCostAssignment(t *testing.T) {
+
+	cost := [][]int{
+		[]int{4, 1, 3},
+		[]int{2, 0, 5},
+		[]int{3, 2, 2},
+	}
+
+	// 1 + 2 + 2 is the cheapest
+	result := minCostAssignment(cost)
+	if result[0] != 1 || result[1] != 0 || result[2] != 2 {
+		t.Fail()
+	}
+}
+
+func TestAllocateCardsEvenly(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}, Minion{ID: 2}, Minion{ID: 3}}
+	cards := []Task{Task{ID: 10}, Task{ID: 20}, Task{ID: 30}, Task{ID: 40}}
+
+	owners := allocateCards(members, cards, nil)
+	if len(owners) != 4 {
+		t.Fatal()
+	}
+
+	count := make(map[int]int)
+	for _, owner := range owners {
+		count[owner]++
+	}
+	// 2, 1, 1 in some order
+	if len(count) != 3 {
+		t.Fail()
+	}
+}
+
+func TestAllocateCardsFollowsBids(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}, Minion{ID: 2}}
+	cards := []Task{Task{ID: 10}, Task{ID: 20}}
+	bids := []Bid{
+		Bid{MinionID: 1, TaskID: 10, Points: 5},  // 1 hates 10
+		Bid{MinionID: 2, TaskID: 20, Points: -1}, // 2 would like 20
+	}
+
+	owners := allocateCards(members, cards, bids)
+	if owners[0] != 1 || owners[1] != 0 {
+		t.Fail()
+	}
+}
+
+func TestAuctionPayment(t *testing.T) {
+
+	gru := Minion{ID: 1}
+	kevin := Minion{ID: 2}
+	members := []Minion{gru, kevin}
+	cards := []Task{Task{ID: 10}, Task{ID: 20}}
+	owners := []int{1, 0} // kevin gets 10, gru gets 20
+	bids := []Bid{
+		Bid{MinionID: 1, TaskID: 10, Points: 5},  // avoided, so pays
+		Bid{MinionID: 1, TaskID: 20, Points: 2},  // didn't work out
+		Bid{MinionID: 2, TaskID: 10, Points: -3}, // claimed, so pays
+		Bid{MinionID: 2, TaskID: 20, Points: -1}, // didn't work out
+	}
+
+	if auctionPayment(gru, cards, members, owners, bids) != 5 {
+		t.Fail()
+	}
+	if auctionPayment(kevin, cards, members, owners, bids) != 3 {
+		t.Fail()
+	}
+}
+
+func TestAuctionCardsLeavesHeldCopies(t *testing.T) {
+
+	// task 1 has 2 copies, but one is still pending in someone's hand during the auction
+	available := []Task{Task{ID: 1, Count: 1}, Task{ID: 2, Count: 2}}
+
+	cards := auctionCards(available, map[uint32]uint32{})
+	if len(cards) != 3 || cards[0].ID != 1 || cards[1].ID != 2 || cards[2].ID != 2 {
+		t.Error(cards)
+	}
+
+	// and copies claimed but not drawn yet aren't auctioned twice
+	cards = auctionCards(available, map[uint32]uint32{2: 1})
+	if len(cards) != 2 {
+		t.Error(cards)
+	}
+}
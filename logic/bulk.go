@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+// BulkAction is something done to a number of pending assignments at once
+type BulkAction string
+
+const (
+	DismissAll     BulkAction = "dismiss"       // back in the deck without counting as done
+	DoneReturnAll  BulkAction = "done_returned" // done and shuffled back in
+	DoneStashAll   BulkAction = "done_stashed"  // done and kept out of the deck
+	KeepMostRecent BulkAction = "keep_recent"   // dismiss all but the most recent N
+)
+
+var (
+	ErrUnknownAction = errors.New("Unknown bulk action")
+	ErrNotOverdue    = errors.New("Only overdue cards can be changed all at once")
+)
+
+// PlanBulkAction works out the new status of every selected assignment, they all have to be among the pending ones
+// and overdue as seen on now, the same as on the overview. For KeepMostRecent the keep most recently drawn ones
+// stay pending.
+func PlanBulkAction(action BulkAction, pending []TaskAssignment, selected []uint32, keep int, now time.Time, calendarFor func(domainID uint32) util.Calendar) (map[uint32]AssignmentStatus, error) {
+
+	byID := make(map[uint32]TaskAssignment)
+	for _, assignment := range pending {
+		byID[assignment.ID] = assignment
+	}
+
+	_, _, overdue := SplitTaskAssignments(pending, now, calendarFor)
+	isOverdue := make(map[uint32]bool)
+	for _, assignment := range overdue {
+		isOverdue[assignment.ID] = true
+	}
+
+	var chosen []TaskAssignment
+	for _, id := range selected {
+		assignment, exists := byID[id]
+		if !exists {
+			return nil, ErrNotYourAssignment
+		}
+		if !isOverdue[id] {
+			return nil, ErrNotOverdue
+		}
+		chosen = append(chosen, assignment)
+	}
+
+	var status AssignmentStatus
+	switch action {
+	case DismissAll, KeepMostRecent:
+		status = Returned
+	case DoneReturnAll:
+		status = DoneAndAvailable
+	case DoneStashAll:
+		status = DoneAndStashed
+	default:
+		return nil, ErrUnknownAction
+	}
+
+	if action == KeepMostRecent {
+		sort.SliceStable(chosen, func(i, j int) bool {
+			return util.StrDateFromTime(chosen[i].AssignedDate.Time) > util.StrDateFromTime(chosen[j].AssignedDate.Time)
+		})
+		if keep < 0 {
+			keep = 0
+		}
+		if keep > len(chosen) {
+			keep = len(chosen)
+		}
+		chosen = chosen[keep:]
+	}
+
+	result := make(map[uint32]AssignmentStatus)
+	for _, assignment := range chosen {
+		if err := assignment.CanTransition(status, Holder); err != nil {
+			return nil, err
+		}
+		result[assignment.ID] = status
+	}
+
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+func bulkPending() []TaskAssignment {
+
+	start := util.DateFromYYYYMMDD(2019, time.February, 1)
+
+	var result []TaskAssignment
+	for i := 0; i < 4; i++ {
+		assignment := NewTaskAssignment(Task{ID: uint32(i + 1)}, Minion{ID: 1}, start.AddDate(0, 0, i))
+		assignment.ID = uint32(10 + i)
+		assignment.AgeInDays = uint32(util.DaysBetween(assignment.AssignedDate.Time, bulkNow))
+		result = append(result, assignment)
+	}
+
+	return result
+}
+
+// all the cards from bulkPending are overdue by then
+var bulkNow = util.DateFromYYYYMMDD(2019, time.February, 10)
+
+func bulkCalendar(domainID uint32) util.Calendar {
+	return util.DefaultCalendar
+}
+
+func TestPlanBulkAction(t *testing.T) {
+
+	plan, err := PlanBulkAction(DismissAll, bulkPending(), []uint32{10, 11}, 0, bulkNow, bulkCalendar)
+	if err != nil || len(plan) != 2 || plan[10] != Returned || plan[11] != Returned {
+		t.Fail()
+	}
+
+	plan, err = PlanBulkAction(DoneStashAll, bulkPending(), []uint32{12}, 0, bulkNow, bulkCalendar)
+	if err != nil || len(plan) != 1 || plan[12] != DoneAndStashed {
+		t.Fail()
+	}
+
+	plan, err = PlanBulkAction(DoneReturnAll, bulkPending(), nil, 0, bulkNow, bulkCalendar)
+	if err != nil || len(plan) != 0 {
+		t.Fail()
+	}
+}
+
+func TestPlanBulkActionRejects(t *testing.T) {
+
+	// someone else's card, or one that isn't pending anymore
+	if _, err := PlanBulkAction(DismissAll, bulkPending(), []uint32{10, 99}, 0, bulkNow, bulkCalendar); err != ErrNotYourAssignment {
+		t.Fail()
+	}
+
+	if _, err := PlanBulkAction(BulkAction("burn"), bulkPending(), []uint32{10}, 0, bulkNow, bulkCalendar); err != ErrUnknownAction {
+		t.Fail()
+	}
+
+	// already done
+	pending := bulkPending()
+	pending[0].Status = DoneAndStashed
+	if _, err := PlanBulkAction(DoneReturnAll, pending, []uint32{10}, 0, bulkNow, bulkCalendar); err != ErrInvalidTransition {
+		t.Fail()
+	}
+}
+
+func TestPlanKeepMostRecent(t *testing.T) {
+
+	// the 2 drawn last stay
+	plan, err := PlanBulkAction(KeepMostRecent, bulkPending(), []uint32{10, 11, 12, 13}, 2, bulkNow, bulkCalendar)
+	if err != nil || len(plan) != 2 || plan[10] != Returned || plan[11] != Returned {
+		t.Fail()
+	}
+
+	plan, err = PlanBulkAction(KeepMostRecent, bulkPending(), []uint32{10, 11}, 5, bulkNow, bulkCalendar)
+	if err != nil || len(plan) != 0 {
+		t.Fail()
+	}
+}
+
+func TestPlanBulkActionOnlyOverdue(t *testing.T) {
+
+	// on Tuesday the 5th a card drawn that day is for today, and a weekly one drawn on Monday is for this week
+	now := util.DateFromYYYYMMDD(2019, time.February, 5)
+	pending := bulkPending()
+	pending[2].AssignedDate.Time = now
+	pending[3].Task.Weekly = true
+	for i := range pending {
+		pending[i].AgeInDays = uint32(util.DaysBetween(pending[i].AssignedDate.Time, now))
+	}
+
+	if _, err := PlanBulkAction(DismissAll, pending, []uint32{10, 12}, 0, now, bulkCalendar); err != ErrNotOverdue {
+		t.Error(err)
+	}
+	if _, err := PlanBulkAction(DoneStashAll, pending, []uint32{13}, 0, now, bulkCalendar); err != ErrNotOverdue {
+		t.Error(err)
+	}
+
+	plan, err := PlanBulkAction(DismissAll, pending, []uint32{10, 11}, 0, now, bulkCalendar)
+	if err != nil || len(plan) != 2 {
+		t.Error(err)
+	}
+}
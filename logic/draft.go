@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"errors"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+var (
+	ErrNotYourTurn = errors.New("It's not your turn to pick")
+	ErrCardGone    = errors.New("That card has already been picked")
+)
+
+// Drafter is a member taking part in the draft, with the cards they picked so far
+type Drafter struct {
+	DomainMember
+	Picks []Task
+}
+
+// DraftBoard is the state of the draft of a domain for the current period
+type DraftBoard struct {
+	Domain    Domain
+	Drafters  []Drafter
+	Remaining []Task // Count is the number of copies still in the deck
+	Turn      int    // index into Drafters, -1 when the draft is over
+}
+
+func (draft DraftBoard) Done() bool {
+	return draft.Turn < 0
+}
+
+// IsTurnOf says if it is the minion's turn to pick
+func (draft DraftBoard) IsTurnOf(minion Minion) bool {
+	return !draft.Done() && draft.Drafters[draft.Turn].ID == minion.ID
+}
+
+// LoadDraft returns the draft of a domain as it stands now
+func LoadDraft(domain Domain) (DraftBoard, error) {
+
+	var draft DraftBoard
+
+	err := db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+		var err error
+		draft, err = loadDraft(dtx)
+		return err
+	})
+
+	return draft, err
+}
+
+// DraftPick claims a card for the minion, if it is their turn and the card is still in the deck
+func DraftPick(minion Minion, domain Domain, taskID uint32) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		draft, err := loadDraft(dtx)
+		if err != nil {
+			return err
+		}
+
+		if !draft.IsTurnOf(minion) {
+			return ErrNotYourTurn
+		}
+
+		for _, task := range draft.Remaining {
+			if task.ID == taskID {
+				return dtx.AllocationInsert(minion, task)
+			}
+		}
+
+		return ErrCardGone
+	})
+}
+
+func loadDraft(dtx *db.DomainTx) (DraftBoard, error) {
+
+	draft := DraftBoard{Domain: dtx.Domain}
+
+	members, err := dtx.Members()
+	if err != nil {
+		return draft, err
+	}
+
+	// copies still in someone's hand from before the draft can't be picked
+	available, err := dtx.AvailableTasks()
+	if err != nil {
+		return draft, err
+	}
+
+	undrawn, err := dtx.UndrawnAllocations()
+	if err != nil {
+		return draft, err
+	}
+
+	allocations, err := dtx.Allocations()
+	if err != nil {
+		return draft, err
+	}
+
+	// viewers don't do chores, so they don't get to pick any either
+	for _, member := range members {
+		if member.Role.Can(DrawCards) {
+			drafter := Drafter{DomainMember: member}
+			for _, allocation := range allocations {
+				if allocation.MinionID == member.ID {
+					drafter.Picks = append(drafter.Picks, allocation.Task)
+				}
+			}
+			draft.Drafters = append(draft.Drafters, drafter)
+		}
+	}
+
+	draft.Remaining = remainingCards(available, undrawn)
+
+	draft.Turn = -1
+	if len(draft.Remaining) > 0 && len(draft.Drafters) > 0 {
+		draft.Turn = snakeTurn(len(allocations), len(draft.Drafters))
+	}
+
+	return draft, nil
+}
+
+// remainingCards returns the tasks that still have copies nobody claimed. Available has the copies that are
+// in the deck, undrawn the claimed ones among them.
+func remainingCards(available []Task, undrawn map[uint32]uint32) []Task {
+
+	var result []Task
+	for _, task := range available {
+		// the count can be lowered after cards were picked
+		if task.Count > undrawn[task.ID] {
+			task.Count -= undrawn[task.ID]
+			result = append(result, task)
+		}
+	}
+
+	return result
+}
+
+// snakeTurn returns who makes the given pick (counting from 0). The order reverses every round
+// so whoever picks first in one round picks last in the next: 0 1 2 2 1 0 0 1 2 ...
+func snakeTurn(pick, drafters int) int {
+
+	round := pick / drafters
+	turn := pick % drafters
+	if round%2 == 1 {
+		turn = drafters - 1 - turn
+	}
+
+	return turn
+}
+
+// DraftsWaitingFor returns the domains where the minion is up to pick a card
+func DraftsWaitingFor(minion Minion, domains []Domain) ([]Domain, error) {
+
+	var result []Domain
+
+	for _, domain := range domains {
+		if domain.AssignmentMode != Draft || !domain.Role.Can(DrawCards) {
+			continue
+		}
+
+		draft, err := LoadDraft(domain)
+		if err != nil {
+			return nil, err
+		}
+		if draft.IsTurnOf(minion) {
+			result = append(result, domain)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"testing"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+func TestSnakeTurn(t *testing.T) {
+
+	expected := []int{0, 1, 2, 2, 1, 0, 0, 1, 2}
+	for pick, turn := range expected {
+		if snakeTurn(pick, 3) != turn {
+			t.Fail()
+		}
+	}
+
+	// alone you always pick
+	if snakeTurn(5, 1) != 0 {
+		t.Fail()
+	}
+}
+
+func TestRemainingCards(t *testing.T) {
+
+	available := []Task{
+		Task{ID: 1, Count: 2},
+		Task{ID: 2, Count: 1},
+		Task{ID: 3, Count: 1},
+	}
+	undrawn := map[uint32]uint32{
+		1: 1,
+		2: 2, // count lowered after it was picked twice
+	}
+
+	remaining := remainingCards(available, undrawn)
+	if len(remaining) != 2 {
+		t.Fatal()
+	}
+	if remaining[0].ID != 1 || remaining[0].Count != 1 || remaining[1].ID != 3 || remaining[1].Count != 1 {
+		t.Fail()
+	}
+}
+
+func TestRemainingCardsHeldFromBefore(t *testing.T) {
+
+	// two copies, one still pending from before the draft so only one is in the deck
+	available := []Task{Task{ID: 1, Count: 1}}
+
+	remaining := remainingCards(available, map[uint32]uint32{})
+	if len(remaining) != 1 || remaining[0].Count != 1 {
+		t.Fail()
+	}
+
+	// and once that one is claimed there is nothing left to pick
+	if len(remainingCards(available, map[uint32]uint32{1: 1})) != 0 {
+		t.Fail()
+	}
+}
+
+func TestDraftBoardTurn(t *testing.T) {
+
+	gru := Minion{ID: 1}
+	kevin := Minion{ID: 2}
+	draft := DraftBoard{
+		Drafters: []Drafter{Drafter{DomainMember: DomainMember{Minion: gru}}, Drafter{DomainMember: DomainMember{Minion: kevin}}},
+		Turn:     1,
+	}
+
+	if draft.IsTurnOf(gru) || !draft.IsTurnOf(kevin) {
+		t.Fail()
+	}
+
+	draft.Turn = -1
+	if !draft.Done() || draft.IsTurnOf(kevin) {
+		t.Fail()
+	}
+}
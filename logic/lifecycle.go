@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/util"
+)
+
+var (
+	ErrNoSuchAssignment = errors.New("No such assignment")
+	ErrSnoozeTooSoon    = errors.New("Cards can only be snoozed until a later day")
+	ErrDrawnAgain       = errors.New("Someone drew that card again in the meantime")
+)
+
+// ChangeStatus is how a minion changes the status of one of their cards. It checks the card is theirs
+// and the change is allowed, saves it and hands out the point for doing a chore.
+func ChangeStatus(minion Minion, assignmentID uint32, to AssignmentStatus) (TaskAssignment, error) {
+	return changeStatus(minion, assignmentID, to, pq.NullTime{})
+}
+
+// Snooze moves one of the minion's cards to a later day
+func Snooze(minion Minion, assignmentID uint32, until time.Time) (TaskAssignment, error) {
+
+	if util.DaysBetween(time.Now().In(minion.Location()), until) < 1 {
+		return TaskAssignment{}, ErrSnoozeTooSoon
+	}
+
+	return changeStatus(minion, assignmentID, Snoozed, pq.NullTime{Time: until, Valid: true})
+}
+
+func changeStatus(minion Minion, assignmentID uint32, to AssignmentStatus, snoozedUntil pq.NullTime) (TaskAssignment, error) {
+
+	assignment := db.AssignmentRetrieve(int64(assignmentID), time.Now().In(minion.Location()))
+	if assignment == nil {
+		return TaskAssignment{}, ErrNoSuchAssignment
+	}
+
+	changed, err := assignment.TransitionBy(minion, to)
+	if err != nil {
+		return *assignment, err
+	}
+	if to == Snoozed {
+		changed.SnoozedUntil = snoozedUntil
+	}
+
+	domain, err := db.GetDomainByID(assignment.Task.DomainID)
+	if err != nil {
+		return *assignment, err
+	}
+
+	// the card and its point change together, so undoing it can take the point back
+	err = db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		err := dtx.AssignmentTransition(changed, assignment.Status)
+		if err == sql.ErrNoRows {
+			// done or moved in the meantime
+			return ErrInvalidTransition
+		}
+		if err != nil {
+			return err
+		}
+
+		if to.IsDone() {
+			// a point for every chore done, to spend in auctions
+			return dtx.MemberEarnPoints(changed, 1)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return *assignment, err
+	}
+
+	return changed, nil
+}
+
+// Undo takes back the last time the minion finished or skipped one of their cards, as long as that was
+// recently enough. A card that went back in the deck can only be taken back if there is still a copy left.
+func Undo(minion Minion, assignmentID uint32) (TaskAssignment, error) {
+
+	assignment := db.AssignmentRetrieve(int64(assignmentID), time.Now().In(minion.Location()))
+	if assignment == nil {
+		return TaskAssignment{}, ErrNoSuchAssignment
+	}
+
+	err := assignment.CanUndo(minion, time.Now())
+	if err != nil {
+		return *assignment, err
+	}
+
+	domain, err := db.GetDomainByID(assignment.Task.DomainID)
+	if err != nil {
+		return *assignment, err
+	}
+
+	err = db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		// stashed cards never left the minion's hand
+		if assignment.Status != DoneAndStashed {
+			available, err := dtx.AvailableTasks()
+			if err != nil {
+				return err
+			}
+			left := TaskFilter(available, func(t Task) bool {
+				return t.ID == assignment.Task.ID && t.Count > 0
+			})
+			if len(left) == 0 {
+				return ErrDrawnAgain
+			}
+		}
+
+		err := dtx.AssignmentUndo(*assignment)
+		if err == sql.ErrNoRows {
+			// changed again in the meantime
+			return ErrInvalidTransition
+		}
+		return err
+	})
+	if err != nil {
+		return *assignment, err
+	}
+
+	return assignment.Undone(), nil
+}
@@ -13,50 +13,166 @@ import (
 
 /*
 	So this is a bit too complex at the moment:
-	- Every day a minion gets a new task for each of their Domains (I might rename that)
-	- At the 1st of the month, every task gets 'shuffled back in'
+	- Every day a minion gets a new task, or as many as the domain hands out, for each of their Domains (I might rename that)
+	- Every week, fortnight, month or when the deck runs out (the domain decides), every task gets 'shuffled back in'
 	- After completing a task, the minion can stash the card or shuffle back in
-	- If all cards run out before the end of the month.... that's fine I guess?
-	- If a minion has missed days, retroactively assign tasks to them
+	- If all cards run out before the reset the domain can reshuffle the done cards, or leave everyone without a card
+	- If a minion has missed days, retroactively assign tasks to them, except for days they said they'd be away
 */
 func Update(minion Minion) error {
 
-	today := time.Now()
+	// the minion's own today, which can be a different date than the server's
+	today := time.Now().In(minion.Location())
 
-	domains := db.GetDomainsForMinion(minion)
+	// viewers only get to look at the board
+	domains := DomainFilter(db.GetDomainsForMinion(minion), func(d Domain) bool { return d.Role.Can(DrawCards) })
 
-	assignments := db.AssignmentRetrieveForMinion(minion, true)
-
-	availableForDomain := make(map[uint32][]Task)
+	away, err := db.GetAwayPeriods(minion)
+	if err != nil {
+		return err
+	}
+	minion.Away = away
 
 	for _, domain := range domains {
 
-		// Avoid resetting every domain every time we run Update() on the 1st of the month
-		if today.Day() == 1 && domain.LastResetDate.Month() != today.Month() {
-			db.ResetAllCompletedTasks(domain)
+		err = db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+			return drawForDomain(dtx, minion, today)
+		})
+		if err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// drawForDomain runs while holding the draw lock of the domain, so no other member can take
+// what this minion sees as available before the new assignments are saved
+func drawForDomain(dtx *db.DomainTx, minion Minion, today time.Time) error {
+
+	domain := dtx.Domain
 
-		available, err := db.GetAvailableTasksForDomain(domain)
+	// resets and rotations happen for everyone at once, so they follow the domain's clock
+	domainToday := today.In(domain.Location())
+
+	deckEmpty := false
+	if domain.ResetCadence == EmptyDeckReset {
+		deck, err := dtx.AvailableTasks()
 		if err != nil {
 			return err
 		}
-		availableForDomain[domain.ID] = available
+		deckEmpty = cardsLeft(deck) == 0
 	}
 
-	tasksToAssign, err := assignTasks(minion, domains, availableForDomain, assignments, today)
+	if resetDue(domain, domainToday, deckEmpty) {
+		err := dtx.ResetAllCompletedTasks(domainToday)
+		if err != nil {
+			return err
+		}
+
+		if domain.AssignmentMode == Auction {
+			err = holdAuction(dtx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if domain.AssignmentMode == Rotation {
+		return dealRotation(dtx, domainToday)
+	}
+
+	// only the periods away from this domain or from all of them
+	minion.Away = AwayPeriodFilter(minion.Away, func(a AwayPeriod) bool { return a.AppliesTo(domain.ID) })
+
+	available, err := drawableTasks(dtx, minion)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := dtx.AssignmentsForMinion(minion, today)
+	if err != nil {
+		return err
+	}
+
+	// the deck ran out before the next reset, once a day is enough
+	if cardsLeft(available) == 0 && domain.ExhaustionPolicy != KeepEmpty && !drewOn(assignments, today) && !domain.ReshuffledOn(domainToday) {
+		available, err = reshuffleDeck(dtx, minion, domainToday)
+		if err != nil {
+			return err
+		}
+	}
+
+	availableForDomain := map[uint32][]Task{domain.ID: available}
+	tasksToAssign, err := assignTasks(minion, []Domain{domain}, availableForDomain, assignments, today)
 	if err != nil {
 		return err
 	}
 
 	for _, t := range tasksToAssign {
 		if t.Task.ID != NoTask.ID {
-			db.AssignmentInsert(t)
+			err = dtx.AssignmentInsert(t)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// drawableTasks returns what the minion can draw from. In draft mode everyone only draws from the cards
+// they picked, in auction mode from the ones they won. Until the first auction is held cards are drawn
+// from the deck as usual.
+func drawableTasks(dtx *db.DomainTx, minion Minion) ([]Task, error) {
+
+	allocated := dtx.Domain.AssignmentMode == Draft
+	if dtx.Domain.AssignmentMode == Auction {
+		held, err := dtx.HasAllocations()
+		if err != nil {
+			return nil, err
+		}
+		allocated = held
+	}
+
+	if allocated {
+		return dtx.AllocatedTasks(minion)
+	}
+
+	return dtx.AvailableTasks()
+}
+
+// reshuffleDeck puts cards back in the deck according to the exhaustion policy of the domain, and returns
+// what the minion can draw after that. Like a reset this starts a new draft or auction.
+func reshuffleDeck(dtx *db.DomainTx, minion Minion, today time.Time) ([]Task, error) {
+
+	err := dtx.Reshuffle(today, dtx.Domain.ExhaustionPolicy == ReshuffleAll)
+	if err != nil {
+		return nil, err
+	}
+
+	if dtx.Domain.AssignmentMode == Auction {
+		err = holdAuction(dtx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return drawableTasks(dtx, minion)
+}
+
+// drewOn is true when one of the assignments was drawn on the day
+func drewOn(assignments []TaskAssignment, day time.Time) bool {
+
+	for _, assignment := range assignments {
+		if util.DateEqual(assignment.AssignedDate.Time, day) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func assignTasks(minion Minion, domains []Domain, availableForDomain map[uint32][]Task, assignments []TaskAssignment, upToIncluding time.Time) ([]TaskAssignment, error) {
 
 	var result []TaskAssignment
@@ -78,7 +194,7 @@ func assignTasks(minion Minion, domains []Domain, availableForDomain map[uint32]
 			return true
 		})
 
-		additional, err := assignTasksForDomain(minion, available, assignments, upToIncluding)
+		additional, err := assignTasksForDomain(minion, domain.CardsPerDay(), available, assignments, upToIncluding)
 		if err != nil {
 			return nil, err
 		}
@@ -88,7 +204,7 @@ func assignTasks(minion Minion, domains []Domain, availableForDomain map[uint32]
 	return result, nil
 }
 
-func assignTasksForDomain(minion Minion, available []Task, assignments []TaskAssignment, upToIncluding time.Time) ([]TaskAssignment, error) {
+func assignTasksForDomain(minion Minion, perDay int, available []Task, assignments []TaskAssignment, upToIncluding time.Time) ([]TaskAssignment, error) {
 
 	var result []TaskAssignment
 
@@ -104,23 +220,42 @@ func assignTasksForDomain(minion Minion, available []Task, assignments []TaskAss
 	if len(assignments) == 0 {
 		// this minion was either added to this Domain, or the Domain is new today or it was reset
 		// result = append(result, NewTaskAssignment(available[0], minion, upToIncluding))
-		return []TaskAssignment{NewTaskAssignment(available[0], minion, upToIncluding)}, nil
+		if !minion.DrawsOn(upToIncluding, upToIncluding) {
+			return nil, nil
+		}
+		for i := 0; i < perDay && i < len(available); i++ {
+			result = append(result, NewTaskAssignment(available[i], minion, upToIncluding))
+		}
+		return result, nil
 	}
 
 	// Fill any gaps including today with tasks
-	result, err := fillGapsWithTasks(minion, assignments, available, upToIncluding)
+	result, err := fillGapsWithTasks(minion, perDay, assignments, available, upToIncluding)
 	return result, err
 
 }
 
-// split pending assignments into 3 lists: those for the current date, overdue ones and weekly ones
-// Note: weekly ones become overdue based on the day the week starts
-func SplitTaskAssignments(pendingTaskAssignments []TaskAssignment, now time.Time) ([]TaskAssignment, []TaskAssignment, []TaskAssignment) {
+// split pending and snoozed assignments into 3 lists: those for the current date, overdue ones and weekly ones
+// Note: weekly ones become overdue after the first weekend day of the week they were drawn in,
+// which depends on the calendar of their domain
+func SplitTaskAssignments(pendingTaskAssignments []TaskAssignment, now time.Time, calendarFor func(domainID uint32) util.Calendar) ([]TaskAssignment, []TaskAssignment, []TaskAssignment) {
 
 	var today, thisWeek, overdue []TaskAssignment
 
 	for _, assignment := range pendingTaskAssignments {
 
+		// snoozed ones stay out of sight until the day they were moved to
+		if assignment.Status == Snoozed {
+			days := util.DaysBetween(assignment.DueDate(calendarFor(assignment.Task.DomainID)), now)
+			switch {
+			case days == 0:
+				today = append(today, assignment)
+			case days > 0:
+				overdue = append(overdue, assignment)
+			}
+			continue
+		}
+
 		if assignment.AgeInDays == 0 {
 			today = append(today, assignment)
 			continue
@@ -130,9 +265,8 @@ func SplitTaskAssignments(pendingTaskAssignments []TaskAssignment, now time.Time
 			continue
 		}
 
-		// previous weekend or any weekday later than today must belong to last week
-		day := assignment.AssignedDate.Time.Weekday()
-		if day > now.Weekday() || util.IsWeekendDay(day) {
+		due := assignment.DueDate(calendarFor(assignment.Task.DomainID))
+		if util.DaysBetween(due, now) > 0 {
 			overdue = append(overdue, assignment)
 		} else {
 			thisWeek = append(thisWeek, assignment)
@@ -143,9 +277,9 @@ func SplitTaskAssignments(pendingTaskAssignments []TaskAssignment, now time.Time
 }
 
 /*
-	For every day that doesn't have an assigned task, pick one from the available ones
+	For every day that doesn't have perDay assigned tasks, pick more from the available ones
 */
-func fillGapsWithTasks(minion Minion, assigned []TaskAssignment, available []Task, upToIncluding time.Time) ([]TaskAssignment, error) {
+func fillGapsWithTasks(minion Minion, perDay int, assigned []TaskAssignment, available []Task, upToIncluding time.Time) ([]TaskAssignment, error) {
 
 	var result []TaskAssignment
 
@@ -155,24 +289,26 @@ func fillGapsWithTasks(minion Minion, assigned []TaskAssignment, available []Tas
 
 	dates := makeContiguousDates(oldest, upToIncluding)
 
-	if len(assigned) > len(dates) {
-		return result, errors.New("Somehow there are more tasks assigned than days...")
-	}
-
-	// fill the gaps, days someone didn't log in still generate tasks
-	if len(assigned) < len(dates) {
+	// fill the gaps, days someone didn't log in still generate tasks unless they were away
+	// or it's longer ago than they want filled in
+	if len(assigned) < len(dates)*perDay {
 
 		// TODO: Remove assigned tasks to avoid dupes
 
-		// make a map so we can easily find the missing dates
+		// count per date so we can easily find the missing ones
 		// and use strDates so it's always YYYY-MM-DD and not some time object with a milli off
-		tasksByDate := make(map[string]TaskAssignment)
+		// days that got more cards while the domain handed out more per day are simply full
+		tasksByDate := make(map[string]int)
 		for _, task := range assigned {
-			tasksByDate[util.StrDateFromTime(task.AssignedDate.Time)] = task
+			tasksByDate[util.StrDateFromTime(task.AssignedDate.Time)]++
 		}
 
 		for _, date := range dates {
-			if _, exists := tasksByDate[util.StrDateFromTime(date)]; !exists {
+			if !minion.DrawsOn(date, upToIncluding) {
+				continue
+			}
+
+			for drawn := tasksByDate[util.StrDateFromTime(date)]; drawn < perDay; drawn++ {
 
 				if len(available) == 0 {
 					result = append(result, TaskAssignment{Task: NoTask})
@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"database/sql"
 	"log"
 	"testing"
 	"time"
@@ -86,7 +87,7 @@ func TestAssignTasksForDomainSimple(t *testing.T) {
 		},
 	}
 
-	additional, err := assignTasksForDomain(minion, available, assigned, end)
+	additional, err := assignTasksForDomain(minion, 1, available, assigned, end)
 	if err != nil {
 		t.Fail()
 	}
@@ -106,7 +107,7 @@ func TestAssignTasksForDomainNothingAssigned(t *testing.T) {
 	}
 	assigned := []TaskAssignment{}
 
-	additional, err := assignTasksForDomain(minion, available, assigned, time.Now())
+	additional, err := assignTasksForDomain(minion, 1, available, assigned, time.Now())
 	if err != nil {
 		t.Fail()
 	}
@@ -123,7 +124,7 @@ func TestAssignTasksForDomainNothingAvailable(t *testing.T) {
 	minion := Minion{ID: 1}
 	var available []Task
 
-	additional, err := assignTasksForDomain(minion, available, nil, time.Now())
+	additional, err := assignTasksForDomain(minion, 1, available, nil, time.Now())
 	if err != nil {
 		t.Fail()
 	}
@@ -206,7 +207,7 @@ func TestSplitTaskAssignments(t *testing.T) {
 		},
 	}
 
-	today, thisWeek, overdue := SplitTaskAssignments(pending, now)
+	today, thisWeek, overdue := SplitTaskAssignments(pending, now, func(uint32) Calendar { return DefaultCalendar })
 
 	if len(today) != 3 { // 2 for today + 1 weekly for today
 		t.Fail()
@@ -219,6 +220,95 @@ func TestSplitTaskAssignments(t *testing.T) {
 	}
 }
 
+func TestSplitTaskAssignmentsSundayStart(t *testing.T) {
+
+	now := DateFromYYYYMMDD(2019, time.January, 31) // thursday
+	israel := Calendar{WeekStart: time.Sunday, Weekend: NewWeekdays(time.Friday, time.Saturday)}
+
+	pending := []TaskAssignment{
+		TaskAssignment{
+			AgeInDays:    4,
+			Task:         Task{Weekly: true},
+			AssignedDate: pq.NullTime{Valid: true, Time: DateFromYYYYMMDD(2019, time.January, 27)}, // sun
+		},
+		TaskAssignment{
+			AgeInDays:    5,
+			Task:         Task{Weekly: true},
+			AssignedDate: pq.NullTime{Valid: true, Time: DateFromYYYYMMDD(2019, time.January, 26)}, // sat
+		},
+	}
+
+	// sunday is the first working day of the week, saturday the last day of the weekend before it
+	_, thisWeek, overdue := SplitTaskAssignments(pending, now, func(uint32) Calendar { return israel })
+	if len(thisWeek) != 1 || !DateEqual(thisWeek[0].AssignedDate.Time, pending[0].AssignedDate.Time) || len(overdue) != 1 {
+		t.Fail()
+	}
+
+	// while with a monday start both belong to last week's weekend
+	_, thisWeek, overdue = SplitTaskAssignments(pending, now, func(uint32) Calendar { return DefaultCalendar })
+	if len(thisWeek) != 0 || len(overdue) != 2 {
+		t.Fail()
+	}
+}
+
+func TestSplitTaskAssignmentsFridayWeekend(t *testing.T) {
+
+	now := DateFromYYYYMMDD(2019, time.February, 2) // saturday
+	fridaySaturday := Calendar{WeekStart: time.Monday, Weekend: NewWeekdays(time.Friday, time.Saturday)}
+
+	// same card in 2 domains
+	pending := []TaskAssignment{
+		TaskAssignment{
+			AgeInDays:    5,
+			Task:         Task{DomainID: 1, Weekly: true},
+			AssignedDate: pq.NullTime{Valid: true, Time: DateFromYYYYMMDD(2019, time.January, 28)}, // mon
+		},
+		TaskAssignment{
+			AgeInDays:    5,
+			Task:         Task{DomainID: 2, Weekly: true},
+			AssignedDate: pq.NullTime{Valid: true, Time: DateFromYYYYMMDD(2019, time.January, 28)}, // mon
+		},
+	}
+
+	calendarFor := func(domainID uint32) Calendar {
+		if domainID == 1 {
+			return fridaySaturday
+		}
+		return DefaultCalendar
+	}
+
+	// was due on friday in domain 1, but can still be done today in domain 2
+	_, thisWeek, overdue := SplitTaskAssignments(pending, now, calendarFor)
+	if len(thisWeek) != 1 || thisWeek[0].Task.DomainID != 2 || len(overdue) != 1 || overdue[0].Task.DomainID != 1 {
+		t.Fail()
+	}
+}
+
+func TestSplitSnoozedAssignments(t *testing.T) {
+
+	now := DateFromYYYYMMDD(2019, time.January, 29) // tuesday
+
+	snoozed := func(until time.Time) TaskAssignment {
+		return TaskAssignment{
+			AgeInDays:    3,
+			Status:       Snoozed,
+			AssignedDate: pq.NullTime{Valid: true, Time: DateFromYYYYMMDD(2019, time.January, 26)},
+			SnoozedUntil: pq.NullTime{Valid: true, Time: until},
+		}
+	}
+
+	pending := []TaskAssignment{
+		snoozed(now),
+		snoozed(now.AddDate(0, 0, -1)),
+		snoozed(now.AddDate(0, 0, 2)), // not yet
+	}
+
+	today, thisWeek, overdue := SplitTaskAssignments(pending, now, func(uint32) Calendar { return DefaultCalendar })
+	if len(today) != 1 || len(thisWeek) != 0 || len(overdue) != 1 {
+		t.Fail()
+	}
+}
+
 func TestFillGapsWithTasksNotEnough(t *testing.T) {
 
 	var minion Minion
@@ -232,7 +322,7 @@ func TestFillGapsWithTasksNotEnough(t *testing.T) {
 		},
 	}
 	var available []Task
-	assigned, err := fillGapsWithTasks(minion, assigned, available, end)
+	assigned, err := fillGapsWithTasks(minion, 1, assigned, available, end)
 	if err != nil {
 		t.Fail()
 	}
@@ -247,7 +337,59 @@ func TestFillGapsWithTasksNotEnough(t *testing.T) {
 	}
 }
 
-func TestFillGapsWithTooManyAssigned(t *testing.T) {
+func TestFillGapsWhileAway(t *testing.T) {
+
+	start := DateFromYYYYMMDD(2019, time.February, 1)
+	end := start.AddDate(0, 0, 14)
+
+	// away for a week in the middle
+	minion := Minion{ID: 1, Away: []AwayPeriod{AwayPeriod{StartsOn: start.AddDate(0, 0, 4), EndsOn: start.AddDate(0, 0, 10)}}}
+
+	assigned := []TaskAssignment{NewTaskAssignment(Task{ID: 1}, minion, start)}
+	available := []Task{Task{ID: 2}, Task{ID: 3}, Task{ID: 4}, Task{ID: 5}, Task{ID: 6}, Task{ID: 7}, Task{ID: 8}, Task{ID: 9}}
+
+	additional, err := fillGapsWithTasks(minion, 1, assigned, available, end)
+	if err != nil || len(additional) != 7 {
+		t.Fail()
+	}
+	for _, a := range additional {
+		if minion.IsAwayOn(a.AssignedDate.Time) {
+			t.Fail()
+		}
+	}
+}
+
+func TestFillGapsWithBackfillLimit(t *testing.T) {
+
+	start := DateFromYYYYMMDD(2019, time.February, 1)
+	end := start.AddDate(0, 0, 14)
+
+	// only yesterday and today
+	minion := Minion{ID: 1, BackfillDays: sql.NullInt64{Int64: 1, Valid: true}}
+
+	assigned := []TaskAssignment{NewTaskAssignment(Task{ID: 1}, minion, start)}
+	available := []Task{Task{ID: 2}, Task{ID: 3}, Task{ID: 4}}
+
+	additional, err := fillGapsWithTasks(minion, 1, assigned, available, end)
+	if err != nil || len(additional) != 2 {
+		t.Fail()
+	}
+}
+
+func TestNoFirstCardWhileAway(t *testing.T) {
+
+	today := DateFromYYYYMMDD(2019, time.February, 1)
+	minion := Minion{ID: 1, Away: []AwayPeriod{AwayPeriod{StartsOn: today, EndsOn: today}}}
+
+	additional, err := assignTasksForDomain(minion, 1, []Task{Task{ID: 1}}, nil, today)
+	if err != nil || len(additional) != 0 {
+		t.Fail()
+	}
+}
+
+// Lowering the cards per day used to make a day with more cards than the limit an error. Those cards are now
+// simply kept, and the day counts as full.
+func TestFillGapsKeepsCardsOverTheLimit(t *testing.T) {
 
 	var minion Minion
 
@@ -262,9 +404,69 @@ func TestFillGapsWithTooManyAssigned(t *testing.T) {
 		},
 	}
 
-	var available []Task
-	assigned, err := fillGapsWithTasks(minion, assigned, available, start)
-	if err == nil {
+	// a day's worth and more, drawn while the domain handed out more cards per day
+	available := []Task{Task{ID: 1}, Task{ID: 2}}
+	additional, err := fillGapsWithTasks(minion, 1, assigned, available, start)
+	if err != nil || len(additional) != 0 {
+		t.Fail()
+	}
+}
+
+func TestFillGapsStaysWithinTheLimit(t *testing.T) {
+
+	start := DateFromYYYYMMDD(2019, time.February, 1)
+	end := start.AddDate(0, 0, 2)
+	minion := Minion{ID: 1}
+
+	// plenty of cards left, but one a day is all there is room for
+	assigned := []TaskAssignment{NewTaskAssignment(Task{ID: 1}, minion, start)}
+	available := []Task{Task{ID: 2}, Task{ID: 3}, Task{ID: 4}, Task{ID: 5}, Task{ID: 6}}
+
+	additional, err := fillGapsWithTasks(minion, 1, assigned, available, end)
+	if err != nil || len(additional) != 2 {
+		t.Fatal(err, additional)
+	}
+	if !DateEqual(additional[0].AssignedDate.Time, start.AddDate(0, 0, 1)) || !DateEqual(additional[1].AssignedDate.Time, end) {
+		t.Fail()
+	}
+}
+
+func TestFillGapsWithSeveralPerDay(t *testing.T) {
+
+	start := DateFromYYYYMMDD(2019, time.February, 1)
+	end := start.AddDate(0, 0, 2)
+	minion := Minion{ID: 1}
+
+	// one short on the first day, nothing on the others
+	assigned := []TaskAssignment{NewTaskAssignment(Task{ID: 1}, minion, start)}
+	available := []Task{Task{ID: 2}, Task{ID: 3}, Task{ID: 4}, Task{ID: 5}, Task{ID: 6}, Task{ID: 7}}
+
+	additional, err := fillGapsWithTasks(minion, 2, assigned, available, end)
+	if err != nil || len(additional) != 5 {
+		t.Fail()
+	}
+
+	perDate := make(map[string]int)
+	for _, a := range additional {
+		perDate[StrDateFromTime(a.AssignedDate.Time)]++
+	}
+	if perDate[StrDateFromTime(start)] != 1 || perDate[StrDateFromTime(end)] != 2 {
+		t.Fail()
+	}
+}
+
+func TestAssignTasksForDomainSeveralPerDay(t *testing.T) {
+
+	available := []Task{Task{ID: 1}, Task{ID: 2}, Task{ID: 3}}
+
+	additional, err := assignTasksForDomain(Minion{ID: 1}, 2, available, nil, time.Now())
+	if err != nil || len(additional) != 2 {
+		t.Fail()
+	}
+
+	// no more than there are
+	additional, err = assignTasksForDomain(Minion{ID: 1}, 5, available, nil, time.Now())
+	if err != nil || len(additional) != 3 {
 		t.Fail()
 	}
 }
@@ -297,7 +499,7 @@ func TestFillGapsWithTasksMulti(t *testing.T) {
 		Task{},
 	}
 
-	additionalTasks, err := fillGapsWithTasks(minion, assigned, available, end)
+	additionalTasks, err := fillGapsWithTasks(minion, 1, assigned, available, end)
 	if err != nil {
 		t.Fail()
 	}
@@ -322,7 +524,7 @@ func TestFillGapsWithTasksSingle(t *testing.T) {
 		Task{},
 	}
 
-	additionalTasks, err := fillGapsWithTasks(minion, assigned, available, end)
+	additionalTasks, err := fillGapsWithTasks(minion, 1, assigned, available, end)
 
 	if err != nil {
 		t.Fail()
@@ -0,0 +1,154 @@
+package logic
+
+import (
+	"log"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/util"
+)
+
+// CheckOverdueEvery applies the overdue policies of all domains every interval, it is meant to run
+// in the background for as long as the server runs
+func CheckOverdueEvery(interval time.Duration) {
+
+	for {
+		CheckOverdue(time.Now())
+		time.Sleep(interval)
+	}
+}
+
+// CheckOverdue applies the overdue policy of every domain that has one. A domain that fails is
+// logged and skipped so it doesn't hold up the others.
+func CheckOverdue(now time.Time) {
+
+	domains, err := db.GetDomainsWithOverduePolicy()
+	if err != nil {
+		return
+	}
+
+	for _, domain := range domains {
+		err = db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+			return applyOverduePolicy(dtx, now.In(dtx.Domain.Location()))
+		})
+		if err != nil {
+			log.Printf("Error checking overdue cards of domain %d: %q", domain.ID, err)
+		}
+	}
+}
+
+func applyOverduePolicy(dtx *db.DomainTx, today time.Time) error {
+
+	domain := dtx.Domain
+	if domain.OverduePolicy == KeepOverdue {
+		return nil
+	}
+
+	pending, err := dtx.PendingAssignments()
+	if err != nil {
+		return err
+	}
+
+	lastMoves, err := dtx.LastMoves()
+	if err != nil {
+		return err
+	}
+
+	stale := staleAssignments(pending, lastMoves, domain.Calendar(), today, domain.OverdueDays)
+	if len(stale) == 0 {
+		return nil
+	}
+
+	members, err := dtx.Members()
+	if err != nil {
+		return err
+	}
+
+	// viewers don't do chores, so nothing gets moved to them
+	var candidates []Minion
+	for _, member := range members {
+		if member.Role.Can(DrawCards) {
+			candidates = append(candidates, member.Minion)
+		}
+	}
+	loads := pendingLoads(pending)
+
+	for _, assignment := range stale {
+
+		switch domain.OverduePolicy {
+		case OfferOverdue:
+			err = dtx.OfferToAll(assignment, today, today.AddDate(0, 0, int(domain.OverdueDays)))
+		case ReassignOverdue:
+			to, found := lightestLoad(candidates, loads, uint32(assignment.MinionID.Int64))
+			if !found {
+				continue
+			}
+			err = dtx.AssignmentReassign(assignment, to)
+			loads[to.ID]++
+			loads[uint32(assignment.MinionID.Int64)]--
+		case ReturnOverdue:
+			err = assignment.CanTransition(Returned, System)
+			if err == nil {
+				err = dtx.AssignmentReturn(assignment)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// staleAssignments returns the assignments that have been overdue for at least the given number of days.
+// The count starts over when a card changes hands, so the new holder gets the same number of days.
+func staleAssignments(pending []TaskAssignment, lastMoves map[uint32]time.Time, calendar util.Calendar, today time.Time, days uint32) []TaskAssignment {
+
+	var result []TaskAssignment
+
+	for _, assignment := range pending {
+
+		since := assignment.DueDate(calendar)
+		if moved, exists := lastMoves[assignment.ID]; exists && moved.After(since) {
+			since = moved
+		}
+
+		if util.DaysBetween(since, today) >= int(days) {
+			result = append(result, assignment)
+		}
+	}
+
+	return result
+}
+
+// pendingLoads counts the pending cards of each member
+func pendingLoads(pending []TaskAssignment) map[uint32]int {
+
+	result := make(map[uint32]int)
+	for _, assignment := range pending {
+		result[uint32(assignment.MinionID.Int64)]++
+	}
+
+	return result
+}
+
+// lightestLoad picks the member with the fewest pending cards other than the one holding the card now.
+// On a tie the member that joined first wins.
+func lightestLoad(members []Minion, loads map[uint32]int, holder uint32) (Minion, bool) {
+
+	var result Minion
+	found := false
+
+	for _, member := range members {
+		if member.ID == holder {
+			continue
+		}
+		if !found || loads[member.ID] < loads[result.ID] {
+			result = member
+			found = true
+		}
+	}
+
+	return result, found
+}
@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+func TestStaleAssignments(t *testing.T) {
+
+	today := time.Date(2019, time.February, 10, 12, 0, 0, 0, time.UTC)
+	gru := Minion{ID: 1}
+
+	old := NewTaskAssignment(Task{ID: 1}, gru, today.AddDate(0, 0, -3))
+	old.ID = 1
+	recent := NewTaskAssignment(Task{ID: 2}, gru, today.AddDate(0, 0, -2))
+	recent.ID = 2
+	moved := NewTaskAssignment(Task{ID: 3}, gru, today.AddDate(0, 0, -5))
+	moved.ID = 3
+
+	lastMoves := map[uint32]time.Time{3: today.AddDate(0, 0, -1)}
+
+	stale := staleAssignments([]TaskAssignment{old, recent, moved}, lastMoves, util.DefaultCalendar, today, 3)
+	if len(stale) != 1 || stale[0].ID != 1 {
+		t.Fail()
+	}
+}
+
+func TestStaleWeeklyAssignments(t *testing.T) {
+
+	// weekly cards are due on saturday
+	monday := time.Date(2019, time.February, 4, 0, 0, 0, 0, time.UTC)
+	weekly := NewTaskAssignment(Task{ID: 1, Weekly: true}, Minion{ID: 1}, monday)
+
+	if len(staleAssignments([]TaskAssignment{weekly}, nil, util.DefaultCalendar, monday.AddDate(0, 0, 5), 1)) != 0 {
+		t.Fail()
+	}
+	if len(staleAssignments([]TaskAssignment{weekly}, nil, util.DefaultCalendar, monday.AddDate(0, 0, 5), 0)) != 1 {
+		t.Fail()
+	}
+	if len(staleAssignments([]TaskAssignment{weekly}, nil, util.DefaultCalendar, monday.AddDate(0, 0, 6), 1)) != 1 {
+		t.Fail()
+	}
+
+	// but on friday with a friday/saturday weekend
+	calendar := util.Calendar{WeekStart: time.Sunday, Weekend: util.NewWeekdays(time.Friday, time.Saturday)}
+	if len(staleAssignments([]TaskAssignment{weekly}, nil, calendar, monday.AddDate(0, 0, 5), 1)) != 1 {
+		t.Fail()
+	}
+}
+
+func TestLightestLoad(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}, Minion{ID: 2}, Minion{ID: 3}}
+	loads := pendingLoads([]TaskAssignment{
+		NewTaskAssignment(Task{ID: 1}, members[0], time.Now()),
+		NewTaskAssignment(Task{ID: 2}, members[1], time.Now()),
+		NewTaskAssignment(Task{ID: 3}, members[1], time.Now()),
+	})
+
+	// 3 has nothing to do
+	to, found := lightestLoad(members, loads, 1)
+	if !found || to.ID != 3 {
+		t.Fail()
+	}
+
+	// 1 has less to do than 2
+	to, _ = lightestLoad(members, loads, 3)
+	if to.ID != 1 {
+		t.Fail()
+	}
+
+	// a tie goes to whoever joined first
+	to, _ = lightestLoad(members, map[uint32]int{}, 1)
+	if to.ID != 2 {
+		t.Fail()
+	}
+
+	_, found = lightestLoad([]Minion{Minion{ID: 1}}, loads, 1)
+	if found {
+		t.Fail()
+	}
+}
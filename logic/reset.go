@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+// resetDue says whether the deck of the domain should be reset today. Resets that were missed because
+// nobody loaded the page on the day are caught up on the next Update, one reset covers all of them.
+func resetDue(domain Domain, today time.Time, deckEmpty bool) bool {
+
+	if domain.ResetCadence == EmptyDeckReset {
+		// at most once a day, there might be nothing in the deck at all
+		return deckEmpty && util.DaysBetween(domain.LastResetDate, today) > 0
+	}
+
+	return util.DaysBetween(domain.LastResetDate, lastScheduledReset(domain, today)) > 0
+}
+
+// lastScheduledReset is the most recent day on or before today the domain should have been reset on
+func lastScheduledReset(domain Domain, today time.Time) time.Time {
+
+	calendar := domain.Calendar()
+
+	switch domain.ResetCadence {
+	case WeeklyReset:
+		return calendar.StartOfWeek(today)
+	case BiweeklyReset:
+		// every other week, counting from the week of the last reset
+		start := calendar.StartOfWeek(domain.LastResetDate)
+		fortnights := util.DaysBetween(start, today) / 14
+		return start.AddDate(0, 0, 14*fortnights)
+	}
+
+	y, m, _ := today.Date()
+	if today.Day() < resetDayIn(y, m, domain.ResetDay) {
+		y, m, _ = util.DateFromYYYYMMDD(y, m-1, 1).Date()
+	}
+
+	return util.DateFromYYYYMMDD(y, m, resetDayIn(y, m, domain.ResetDay))
+}
+
+// resetDayIn is the day of the month to reset on, or the last day when the month doesn't have that many days
+func resetDayIn(year int, month time.Month, day uint32) int {
+
+	last := util.DateFromYYYYMMDD(year, month+1, 0).Day()
+	if day < 1 {
+		return 1
+	}
+	if int(day) > last {
+		return last
+	}
+
+	return int(day)
+}
+
+// cardsLeft counts the cards that can still be drawn
+func cardsLeft(available []Task) uint32 {
+
+	var result uint32
+	for _, task := range available {
+		result += task.Count
+	}
+
+	return result
+}
@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+func TestMonthlyReset(t *testing.T) {
+
+	domain := Domain{ResetCadence: MonthlyReset, ResetDay: 1, LastResetDate: util.DateFromYYYYMMDD(2019, time.January, 1)}
+
+	if resetDue(domain, util.DateFromYYYYMMDD(2019, time.January, 31), false) {
+		t.Fail()
+	}
+	if !resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 1), false) {
+		t.Fail()
+	}
+	// nobody showed up on the 1st
+	if !resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 3), false) {
+		t.Fail()
+	}
+
+	// the 31st is the 28th in february
+	domain.ResetDay = 31
+	if !util.DateEqual(lastScheduledReset(domain, util.DateFromYYYYMMDD(2019, time.March, 5)), util.DateFromYYYYMMDD(2019, time.February, 28)) {
+		t.Fail()
+	}
+	if !util.DateEqual(lastScheduledReset(domain, util.DateFromYYYYMMDD(2019, time.January, 31)), util.DateFromYYYYMMDD(2019, time.January, 31)) {
+		t.Fail()
+	}
+
+	// going back a year
+	domain.ResetDay = 15
+	if !util.DateEqual(lastScheduledReset(domain, util.DateFromYYYYMMDD(2019, time.January, 3)), util.DateFromYYYYMMDD(2018, time.December, 15)) {
+		t.Fail()
+	}
+}
+
+func TestWeeklyReset(t *testing.T) {
+
+	// last reset on a wednesday, weeks start on monday
+	domain := Domain{ResetCadence: WeeklyReset, LastResetDate: util.DateFromYYYYMMDD(2019, time.February, 6), OwnerWeekStart: time.Monday}
+
+	if resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 10), false) {
+		t.Fail()
+	}
+	if !resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 11), false) {
+		t.Fail()
+	}
+
+	// but already on sunday when weeks start on sunday
+	domain.OwnerWeekStart = time.Sunday
+	if !resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 10), false) {
+		t.Fail()
+	}
+}
+
+func TestBiweeklyReset(t *testing.T) {
+
+	domain := Domain{ResetCadence: BiweeklyReset, LastResetDate: util.DateFromYYYYMMDD(2019, time.February, 6), OwnerWeekStart: time.Monday}
+
+	if resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 11), false) {
+		t.Fail()
+	}
+	if !resetDue(domain, util.DateFromYYYYMMDD(2019, time.February, 18), false) {
+		t.Fail()
+	}
+	// missed a few, the next one is on the start of the most recent fortnight
+	if !util.DateEqual(lastScheduledReset(domain, util.DateFromYYYYMMDD(2019, time.March, 20)), util.DateFromYYYYMMDD(2019, time.March, 18)) {
+		t.Fail()
+	}
+}
+
+func TestEmptyDeckReset(t *testing.T) {
+
+	today := util.DateFromYYYYMMDD(2019, time.February, 6)
+	domain := Domain{ResetCadence: EmptyDeckReset, LastResetDate: today.AddDate(0, 0, -40)}
+
+	if resetDue(domain, today, false) || !resetDue(domain, today, true) {
+		t.Fail()
+	}
+
+	// only once a day
+	domain.LastResetDate = today
+	if resetDue(domain, today, true) {
+		t.Fail()
+	}
+
+	if cardsLeft([]Task{Task{Count: 0}, Task{Count: 2}}) != 2 || cardsLeft(nil) != 0 {
+		t.Fail()
+	}
+}
+
+func TestDrewOn(t *testing.T) {
+
+	today := time.Date(2019, time.February, 6, 18, 30, 0, 0, time.UTC)
+	yesterday := NewTaskAssignment(Task{ID: 1}, Minion{ID: 1}, util.DateFromYYYYMMDD(2019, time.February, 5))
+
+	if drewOn([]TaskAssignment{yesterday}, today) {
+		t.Fail()
+	}
+
+	drawn := NewTaskAssignment(Task{ID: 2}, Minion{ID: 1}, util.DateFromYYYYMMDD(2019, time.February, 6))
+	if !drewOn([]TaskAssignment{yesterday, drawn}, today) {
+		t.Fail()
+	}
+}
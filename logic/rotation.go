@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"sort"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+	"github.com/niven/taskmaster/util"
+)
+
+// dealRotation hands out cards for every day nobody dealt for yet, to all members at once.
+// That way the order of the wheel doesn't depend on who happens to load the page first.
+func dealRotation(dtx *db.DomainTx, today time.Time) error {
+
+	domain := dtx.Domain
+
+	start := today
+	if domain.RotationDealtUntil.Valid {
+		if util.StrDateFromTime(domain.RotationDealtUntil.Time) >= util.StrDateFromTime(today) {
+			return nil
+		}
+		start = domain.RotationDealtUntil.Time.AddDate(0, 0, 1)
+	}
+
+	members, err := dtx.Members()
+	if err != nil {
+		return err
+	}
+
+	away, err := dtx.AwayPeriods()
+	if err != nil {
+		return err
+	}
+
+	// viewers don't do chores, so the wheel skips them
+	var players []Minion
+	for _, member := range members {
+		if member.Role.Can(DrawCards) {
+			member.Away = AwayPeriodFilter(away, func(a AwayPeriod) bool { return a.MinionID == member.ID })
+			players = append(players, member.Minion)
+		}
+	}
+
+	// after a long gap only the days someone still wants filled in are dealt
+	dates := makeContiguousDates(backfillStart(players, start, today), today)
+
+	tasks, err := dtx.Tasks()
+	if err != nil {
+		return err
+	}
+	cards := deckCards(tasks)
+
+	// the wheel is laid out with every copy so its order stays the same, but copies that are still
+	// in someone's hand can't be dealt again
+	available, err := dtx.AvailableTasks()
+	if err != nil {
+		return err
+	}
+	left := make(map[uint32]uint32)
+	for _, task := range available {
+		left[task.ID] = task.Count
+	}
+
+	// the wheel also skips whoever is away or doesn't want the day filled in, so it has to turn one day at a time
+	position := domain.RotationPosition
+	for _, date := range dates {
+		present := MinionFilter(players, func(m Minion) bool { return m.DrawsOn(date, today) })
+
+		var assignments []TaskAssignment
+		assignments, position = rotateTasks(present, cards, position, []time.Time{date})
+		for _, assignment := range takeAvailable(assignments, left) {
+			err = dtx.AssignmentInsert(assignment)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return dtx.SetRotation(position, today)
+}
+
+// backfillStart moves the first day to deal up to the furthest back any of the players wants missed days
+// filled in. Without players, or when one of them has no limit, it stays where it is.
+func backfillStart(players []Minion, start, today time.Time) time.Time {
+
+	furthest := 0
+	for _, player := range players {
+		if !player.BackfillDays.Valid {
+			return start
+		}
+		if int(player.BackfillDays.Int64) > furthest {
+			furthest = int(player.BackfillDays.Int64)
+		}
+	}
+	if len(players) == 0 {
+		return start
+	}
+
+	earliest := today.AddDate(0, 0, -furthest)
+	if util.StrDateFromTime(start) < util.StrDateFromTime(earliest) {
+		return earliest
+	}
+
+	return start
+}
+
+// takeAvailable keeps the dealt cards that still have a copy left in the deck, and uses those copies up.
+// Once the deck runs out nobody gets a card until copies come back.
+func takeAvailable(assignments []TaskAssignment, left map[uint32]uint32) []TaskAssignment {
+
+	var result []TaskAssignment
+	for _, assignment := range assignments {
+		if left[assignment.Task.ID] > 0 {
+			left[assignment.Task.ID]--
+			result = append(result, assignment)
+		}
+	}
+
+	return result
+}
+
+// deckCards lays out every copy of every task in a fixed order, so the wheel deals the same way every time
+func deckCards(tasks []Task) []Task {
+
+	sorted := make([]Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var result []Task
+	for _, task := range sorted {
+		for i := uint32(0); i < task.Count; i++ {
+			result = append(result, task)
+		}
+	}
+
+	return result
+}
+
+// rotateTasks deals cards like a chore wheel. On every date member i gets the card in slot
+// (position + i) and then the wheel turns one step. When there are more members than cards
+// the wheel has empty slots, so everyone gets a turn at not having a chore.
+// Returns the assignments and the position the wheel stopped at.
+func rotateTasks(members []Minion, cards []Task, position uint32, dates []time.Time) ([]TaskAssignment, uint32) {
+
+	var result []TaskAssignment
+
+	if len(members) == 0 || len(cards) == 0 {
+		return result, position
+	}
+
+	slots := len(cards)
+	if len(members) > slots {
+		slots = len(members)
+	}
+
+	for _, date := range dates {
+		for i, member := range members {
+			slot := (int(position) + i) % slots
+			if slot < len(cards) {
+				result = append(result, NewTaskAssignment(cards[slot], member, date))
+			}
+		}
+		position = (position + 1) % uint32(slots)
+	}
+
+	return result, position
+}
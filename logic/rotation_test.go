@@ -0,0 +1,154 @@
+package logic
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+)
+
+func TestDeckCards(t *testing.T) {
+
+	tasks := []Task{
+		Task{ID: 3, Count: 1},
+		Task{ID: 1, Count: 2},
+		Task{ID: 2, Count: 0},
+	}
+
+	cards := deckCards(tasks)
+	if len(cards) != 3 {
+		t.Fail()
+	}
+	if cards[0].ID != 1 || cards[1].ID != 1 || cards[2].ID != 3 {
+		t.Fail()
+	}
+	// input order is left alone
+	if tasks[0].ID != 3 {
+		t.Fail()
+	}
+}
+
+func TestRotateTasks(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}, Minion{ID: 2}}
+	cards := []Task{Task{ID: 10}, Task{ID: 20}, Task{ID: 30}}
+	start := time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)
+	dates := makeContiguousDates(start, start.AddDate(0, 0, 2))
+
+	assignments, position := rotateTasks(members, cards, 0, dates)
+	if len(assignments) != 6 {
+		t.Fatal()
+	}
+	if position != 0 {
+		t.Fail()
+	}
+
+	// day 1: 10, 20 then day 2: 20, 30 then day 3: 30, 10
+	expected := []uint32{10, 20, 20, 30, 30, 10}
+	for i, assignment := range assignments {
+		if assignment.Task.ID != expected[i] || assignment.MinionID.Int64 != int64(members[i%2].ID) {
+			t.Fail()
+		}
+	}
+	if !assignments[5].AssignedDate.Time.Equal(dates[2]) {
+		t.Fail()
+	}
+}
+
+func TestRotateTasksContinues(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}}
+	cards := []Task{Task{ID: 10}, Task{ID: 20}, Task{ID: 30}}
+	today := []time.Time{time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)}
+
+	assignments, position := rotateTasks(members, cards, 2, today)
+	if len(assignments) != 1 || assignments[0].Task.ID != 30 || position != 0 {
+		t.Fail()
+	}
+}
+
+func TestRotateTasksMoreMembersThanCards(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}, Minion{ID: 2}, Minion{ID: 3}}
+	cards := []Task{Task{ID: 10}}
+	start := time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)
+	dates := makeContiguousDates(start, start.AddDate(0, 0, 2))
+
+	assignments, _ := rotateTasks(members, cards, 0, dates)
+	if len(assignments) != 3 {
+		t.Fatal()
+	}
+
+	// everyone gets the single card once
+	seen := make(map[int64]bool)
+	for _, assignment := range assignments {
+		seen[assignment.MinionID.Int64] = true
+	}
+	if len(seen) != 3 {
+		t.Fail()
+	}
+}
+
+func TestRotateTasksNothingToDeal(t *testing.T) {
+
+	today := []time.Time{time.Now()}
+
+	assignments, position := rotateTasks(nil, []Task{Task{ID: 1}}, 4, today)
+	if len(assignments) != 0 || position != 4 {
+		t.Fail()
+	}
+
+	assignments, _ = rotateTasks([]Minion{Minion{ID: 1}}, nil, 0, today)
+	if len(assignments) != 0 {
+		t.Fail()
+	}
+}
+
+func TestTakeAvailable(t *testing.T) {
+
+	members := []Minion{Minion{ID: 1}}
+	cards := deckCards([]Task{Task{ID: 10, Count: 1}})
+	start := time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	// the only copy is dealt on the first day and still pending on the second
+	left := map[uint32]uint32{10: 1}
+	position := uint32(0)
+	var dealt []TaskAssignment
+	for _, date := range makeContiguousDates(start, start.AddDate(0, 0, 1)) {
+		var assignments []TaskAssignment
+		assignments, position = rotateTasks(members, cards, position, []time.Time{date})
+		dealt = append(dealt, takeAvailable(assignments, left)...)
+	}
+	if len(dealt) != 1 || !dealt[0].AssignedDate.Time.Equal(start) || left[10] != 0 {
+		t.Error(dealt)
+	}
+
+	// the next time it's dealt the copy is in use, so there's nothing to deal
+	assignments, _ := rotateTasks(members, cards, position, []time.Time{start.AddDate(0, 0, 2)})
+	if len(assignments) != 1 || len(takeAvailable(assignments, map[uint32]uint32{10: 0})) != 0 {
+		t.Fail()
+	}
+}
+
+func TestBackfillStart(t *testing.T) {
+
+	today := time.Date(2019, time.February, 20, 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(0, 0, -30)
+
+	capped := []Minion{Minion{ID: 1, BackfillDays: sql.NullInt64{Int64: 2, Valid: true}}, Minion{ID: 2, BackfillDays: sql.NullInt64{Int64: 5, Valid: true}}}
+	if !backfillStart(capped, start, today).Equal(today.AddDate(0, 0, -5)) {
+		t.Fail()
+	}
+
+	// a recent start is left alone
+	if !backfillStart(capped, today.AddDate(0, 0, -1), today).Equal(today.AddDate(0, 0, -1)) {
+		t.Fail()
+	}
+
+	// someone wants every day
+	unlimited := append(capped, Minion{ID: 3})
+	if !backfillStart(unlimited, start, today).Equal(start) {
+		t.Fail()
+	}
+}
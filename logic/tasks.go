@@ -0,0 +1,164 @@
+package logic
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/db"
+)
+
+// TaskEdit is what the deck editor wants a task to look like
+type TaskEdit struct {
+	Task   Task
+	Delete bool
+}
+
+// UpdateTasks saves changes to the tasks of a domain. When a task ends up with fewer copies than are drawn,
+// the surplus ones go back in the deck.
+func UpdateTasks(domain Domain, edits []TaskEdit) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		tasks, err := dtx.Tasks()
+		if err != nil {
+			return err
+		}
+
+		for _, edit := range edits {
+
+			id := edit.Task.ID
+			if len(TaskFilter(tasks, func(t Task) bool { return t.ID == id })) == 0 {
+				return ErrNoSuchTask
+			}
+
+			if edit.Delete {
+				err = dtx.TaskDelete(edit.Task)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err = updateTask(dtx, edit.Task)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AddTasks adds new tasks to a domain, all of them or none
+func AddTasks(domain Domain, tasks []Task) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		for _, task := range tasks {
+			err := dtx.TaskInsert(task)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MergeTasks adds imported tasks to a domain. A task with the same name as one already in the deck, ignoring case,
+// replaces that one; the other tasks in the deck are left alone.
+func MergeTasks(domain Domain, imported []Task) error {
+
+	return db.WithDomainLock(domain, func(dtx *db.DomainTx) error {
+
+		tasks, err := dtx.Tasks()
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[string]Task)
+		for _, t := range tasks {
+			existing[strings.ToLower(strings.TrimSpace(t.Name))] = t
+		}
+
+		for _, task := range imported {
+
+			if t, found := existing[strings.ToLower(strings.TrimSpace(task.Name))]; found {
+				task.ID, task.DomainID = t.ID, t.DomainID
+				err = updateTask(dtx, task)
+				if err == nil && len(task.Tags) > 0 {
+					err = dtx.TaskSetTags(task)
+				}
+			} else {
+				err = dtx.TaskInsert(task)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// updateTask saves the task and puts back the drawn copies it no longer has
+func updateTask(dtx *db.DomainTx, task Task) error {
+
+	err := dtx.TaskUpdate(task)
+	if err != nil {
+		return err
+	}
+
+	drawn, err := dtx.AssignmentsForTask(task, time.Now().In(dtx.Domain.Location()))
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range surplusAssignments(drawn, task.Count) {
+
+		// a stashed card is done already, so it just stops keeping its copy out of the deck
+		to := Returned
+		if assignment.Status == DoneAndStashed {
+			to = DoneAndAvailable
+		}
+
+		err = assignment.CanTransition(to, System)
+		if err == nil {
+			changed := assignment
+			changed.Status, changed.SnoozedUntil = to, pq.NullTime{}
+			err = dtx.AssignmentTransition(changed, assignment.Status)
+		}
+		if err == nil {
+			err = dtx.AssignmentMovedBack(assignment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// surplusAssignments picks the drawn copies of a task to put back when there are more than count of them:
+// stashed ones first since nobody has to do those anymore, then the ones drawn most recently.
+func surplusAssignments(drawn []TaskAssignment, count uint32) []TaskAssignment {
+
+	held := TaskAssignmentFilter(drawn, func(ta TaskAssignment) bool { return ta.Status.HoldsCopy() })
+	if len(held) <= int(count) {
+		return nil
+	}
+
+	sort.SliceStable(held, func(i, j int) bool {
+		stashedI, stashedJ := held[i].Status == DoneAndStashed, held[j].Status == DoneAndStashed
+		if stashedI != stashedJ {
+			return stashedI
+		}
+		return held[i].AssignedDate.Time.After(held[j].AssignedDate.Time)
+	})
+
+	return held[:len(held)-int(count)]
+}
@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/niven/taskmaster/data"
+	"github.com/niven/taskmaster/util"
+)
+
+func TestSurplusAssignments(t *testing.T) {
+
+	start := util.DateFromYYYYMMDD(2019, time.February, 1)
+	statuses := []AssignmentStatus{Pending, DoneAndStashed, DoneAndAvailable, Snoozed, Skipped, Pending}
+
+	var drawn []TaskAssignment
+	for i, status := range statuses {
+		assignment := NewTaskAssignment(Task{ID: 1}, Minion{ID: 1}, start.AddDate(0, 0, i))
+		assignment.ID = uint32(10 + i)
+		assignment.Status = status
+		drawn = append(drawn, assignment)
+	}
+
+	// 4 copies are out of the deck
+	if len(surplusAssignments(drawn, 4)) != 0 || len(surplusAssignments(drawn, 9)) != 0 {
+		t.Fail()
+	}
+
+	// the stashed one goes first, then the newest open ones
+	surplus := surplusAssignments(drawn, 1)
+	if len(surplus) != 3 || surplus[0].ID != 11 || surplus[1].ID != 15 || surplus[2].ID != 13 {
+		t.Fail()
+	}
+
+	if len(surplusAssignments(drawn, 0)) != 4 {
+		t.Fail()
+	}
+}
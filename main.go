@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 
 	"github.com/niven/taskmaster/config"
+	"github.com/niven/taskmaster/deck"
 	. "github.com/niven/taskmaster/handlers"
+	"github.com/niven/taskmaster/logic"
 )
 
 func init() {
@@ -35,6 +38,12 @@ func setupRouting(router *gin.Engine) {
 	{
 		authorized.GET("/today", OverviewHandler)
 		authorized.GET("/setup", SetupHandler)
+		authorized.POST("/setup/timezone", SetupTimezoneHandler)
+		authorized.POST("/setup/calendar", SetupCalendarHandler)
+		authorized.POST("/setup/away", SetupAwayHandler)
+		authorized.GET("/setup/away/remove/:away_id", SetupAwayRemoveHandler)
+		authorized.POST("/setup/backfill", SetupBackfillHandler)
+		authorized.POST("/setup/undo", SetupUndoHandler)
 	}
 
 	domain := router.Group("/domain")
@@ -43,13 +52,53 @@ func setupRouting(router *gin.Engine) {
 		domain.POST("/new", DomainNewHandler)
 		domain.GET("/edit/:domain_id", DomainEditHandler)
 		domain.GET("/delete/:domain_id", DomainDeleteHandler)
+		domain.POST("/member/add", DomainMemberAddHandler)
+		domain.GET("/member/remove/:domain_id/:minion_id", DomainMemberRemoveHandler)
+		domain.POST("/member/role", DomainMemberRoleHandler)
+		domain.POST("/transfer", DomainTransferHandler)
+		domain.GET("/leave/:domain_id", DomainLeaveHandler)
+		domain.GET("/settings/:domain_id", DomainSettingsHandler)
+		domain.POST("/settings", DomainSettingsSaveHandler)
+		domain.POST("/visibility", DomainVisibilityHandler)
+		domain.GET("/shared/:token", DomainSharedHandler)
+		domain.POST("/clone", DomainCloneHandler)
+		domain.GET("/export/:domain_id/:format", DomainExportHandler)
+		domain.POST("/import", DomainImportHandler)
+		domain.POST("/import/confirm", DomainImportConfirmHandler)
+		domain.POST("/mode", DomainModeHandler)
+		domain.POST("/overdue", DomainOverdueHandler)
+		domain.POST("/reset", DomainResetHandler)
+		domain.POST("/exhaustion", DomainExhaustionHandler)
+		domain.POST("/timezone", DomainTimezoneHandler)
+		domain.POST("/calendar", DomainCalendarHandler)
+		domain.GET("/draft/:domain_id", DraftHandler)
+		domain.POST("/draft/pick", DraftPickHandler)
+		domain.GET("/auction/:domain_id", AuctionHandler)
+		domain.POST("/auction/bid", AuctionBidHandler)
+		domain.POST("/auction/hold", AuctionHoldHandler)
+		domain.GET("/market/:domain_id", MarketHandler)
+		domain.POST("/offer/new", OfferNewHandler)
+		domain.GET("/offer/accept/:domain_id/:offer_id", OfferAcceptHandler)
+		domain.GET("/offer/cancel/:domain_id/:offer_id", OfferCancelHandler)
+		domain.POST("/invite/new", DomainInviteNewHandler)
+		domain.GET("/invite/revoke/:domain_id/:invite_id", DomainInviteRevokeHandler)
+		domain.GET("/join/:token", DomainJoinHandler)
+		domain.POST("/join", DomainJoinCodeHandler)
+		domain.GET("/request/:decision/:domain_id/:minion_id", DomainJoinRequestHandler)
+		domain.POST("/approval", DomainApprovalHandler)
 	}
 
 	task := router.Group("/task")
 	task.Use(AuthorizeRequest())
 	{
 		task.POST("/new", TaskNewHandler)
+		task.POST("/quickadd", TaskQuickAddHandler)
+		task.POST("/update", TaskUpdateHandler)
 		task.POST("/done", TaskDoneHandler)
+		task.POST("/bulk", TaskBulkHandler)
+		task.POST("/skip", TaskSkipHandler)
+		task.POST("/snooze", TaskSnoozeHandler)
+		task.POST("/undo", TaskUndoHandler)
 	}
 
 }
@@ -62,6 +111,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = deck.LoadLibrary("decks")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	router := gin.New()
 
 	router.Use(sessions.Sessions("tm", store))
@@ -71,5 +126,7 @@ func main() {
 
 	setupRouting(router)
 
+	go logic.CheckOverdueEvery(time.Hour)
+
 	router.Run(":" + config.EnvironmentVars["PORT"])
 }
@@ -3,9 +3,10 @@ var state = null;
 function list_item_click( event ) {
 	let task_assignment_id = event.target.getAttribute("task-assignment-id");
 	let task_name = event.target.innerHTML;
+	let domain_id = event.target.getAttribute("domain-id");
 
 	console.log( task_assignment_id, task_name );
-	open_modal( task_assignment_id, task_name );
+	open_modal( task_assignment_id, task_name, domain_id );
 }
 
 function mark_task_done( task_assignment_id, return_task ) {
@@ -31,13 +32,34 @@ function mark_task_done( task_assignment_id, return_task ) {
 	close_modal();
 }
 
-function open_modal( task_assignment_id, task_name ) {
+// skip or snooze, both only need the assignment and maybe a date
+function post_task_action( action, params ) {
+
+	var xhr = new XMLHttpRequest();
+	xhr.open("POST", '/task/' + action, true);
+
+	xhr.setRequestHeader("Content-Type", "application/x-www-form-urlencoded");
+
+	xhr.onreadystatechange = function() {
+	    if (this.readyState === XMLHttpRequest.DONE && this.status === 200) {
+		 	location = "/today"
+	    }
+	}
+	xhr.send( params );
+
+	close_modal();
+}
+
+function open_modal( task_assignment_id, task_name, domain_id ) {
 	
 	let modal_title = document.getElementById("modal-task-title");
 	modal_title.innerHTML = task_name;
 
 	document.querySelector("#done-return-button").setAttribute("task-assignment-id", task_assignment_id);
 	document.querySelector("#done-stash-button").setAttribute("task-assignment-id", task_assignment_id);
+	document.querySelector("#skip-button").setAttribute("task-assignment-id", task_assignment_id);
+	document.querySelector("#snooze-button").setAttribute("task-assignment-id", task_assignment_id);
+	document.querySelector("#offer-button").setAttribute("domain-id", domain_id);
 
 	["modal", "modal-overlay"].forEach( dom_id => document.getElementById(dom_id).classList.toggle("closed") );
 }
@@ -52,10 +74,16 @@ function setup_modal() {
 	let close_button = document.querySelector("#close-button");
 	let done_return_button = document.querySelector("#done-return-button");
 	let done_stash_button = document.querySelector("#done-stash-button");
+	let offer_button = document.querySelector("#offer-button");
+	let skip_button = document.querySelector("#skip-button");
+	let snooze_button = document.querySelector("#snooze-button");
 
 	close_button.onclick = close_modal;
 	done_return_button.onclick = function( event ) { mark_task_done( event.target.getAttribute("task-assignment-id"), true ) };
 	done_stash_button.onclick = function( event ) { mark_task_done( event.target.getAttribute("task-assignment-id"), false ) };
+	offer_button.onclick = function( event ) { location = "/domain/market/" + offer_button.getAttribute("domain-id") };
+	skip_button.onclick = function( event ) { post_task_action( "skip", "task_assignment_id=" + skip_button.getAttribute("task-assignment-id") ) };
+	snooze_button.onclick = function( event ) { post_task_action( "snooze", "task_assignment_id=" + snooze_button.getAttribute("task-assignment-id") + "&until=" + document.getElementById("snooze-until").value ) };
 }
 
 function clear( element ) {
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"time"
+)
+
+// Weekdays is a set of days of the week, bit 0 is Sunday
+type Weekdays uint8
+
+func NewWeekdays(days ...time.Weekday) Weekdays {
+
+	var result Weekdays
+	for _, day := range days {
+		result |= 1 << uint(day)
+	}
+
+	return result
+}
+
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w&(1<<uint(day)) != 0
+}
+
+// Calendar is which day weeks start on and which days are the weekend, both differ around the world
+type Calendar struct {
+	WeekStart time.Weekday
+	Weekend   Weekdays
+}
+
+// DefaultCalendar has weeks starting on Monday and a Saturday/Sunday weekend
+var DefaultCalendar = Calendar{WeekStart: time.Monday, Weekend: NewWeekdays(time.Saturday, time.Sunday)}
+
+func (c Calendar) IsWeekendDay(day time.Weekday) bool {
+	return c.Weekend.Has(day)
+}
+
+// Days returns the days of the week in order, starting with the first one
+func (c Calendar) Days() []time.Weekday {
+
+	result := make([]time.Weekday, 7)
+	for i := range result {
+		result[i] = (c.WeekStart + time.Weekday(i)) % 7
+	}
+
+	return result
+}
+
+// StartOfWeek returns the first day of the week t is in
+func (c Calendar) StartOfWeek(t time.Time) time.Time {
+	return t.AddDate(0, 0, -int((7+t.Weekday()-c.WeekStart)%7))
+}
+
+// EndOfWeek returns the last day of the week t is in
+func (c Calendar) EndOfWeek(t time.Time) time.Time {
+	return c.StartOfWeek(t).AddDate(0, 0, 6)
+}
+
+// WeeklyDueDate is the last day to do a weekly chore assigned on a date: the first weekend day
+// of that week, or the last day of the week if the weekend has already passed
+func (c Calendar) WeeklyDueDate(assigned time.Time) time.Time {
+
+	due := assigned
+	end := c.EndOfWeek(assigned)
+	for !c.IsWeekendDay(due.Weekday()) && due.Before(end) {
+		due = due.AddDate(0, 0, 1)
+	}
+
+	return due
+}
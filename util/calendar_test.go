@@ -0,0 +1,87 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// Israel: weeks start on Sunday, the weekend is Friday and Saturday
+var sundayStart = Calendar{WeekStart: time.Sunday, Weekend: NewWeekdays(time.Friday, time.Saturday)}
+
+func TestWeekdays(t *testing.T) {
+
+	w := NewWeekdays(time.Friday, time.Saturday)
+	if !w.Has(time.Friday) || !w.Has(time.Saturday) || w.Has(time.Sunday) {
+		t.Fail()
+	}
+
+	if NewWeekdays(time.Saturday, time.Sunday) != 65 {
+		t.Fail()
+	}
+}
+
+func TestCalendarDays(t *testing.T) {
+
+	days := DefaultCalendar.Days()
+	if days[0] != time.Monday || days[6] != time.Sunday {
+		t.Fail()
+	}
+
+	days = sundayStart.Days()
+	if days[0] != time.Sunday || days[6] != time.Saturday {
+		t.Fail()
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+
+	sunday := DateFromYYYYMMDD(2019, time.February, 3)
+	wednesday := DateFromYYYYMMDD(2019, time.February, 6)
+
+	if !DateEqual(DefaultCalendar.StartOfWeek(wednesday), DateFromYYYYMMDD(2019, time.February, 4)) {
+		t.Fail()
+	}
+	// sunday is the end of the week that started on monday the 28th
+	if !DateEqual(DefaultCalendar.StartOfWeek(sunday), DateFromYYYYMMDD(2019, time.January, 28)) {
+		t.Fail()
+	}
+	if !DateEqual(DefaultCalendar.EndOfWeek(wednesday), DateFromYYYYMMDD(2019, time.February, 10)) {
+		t.Fail()
+	}
+
+	// but the start of a week starting on sunday
+	if !DateEqual(sundayStart.StartOfWeek(sunday), sunday) || !DateEqual(sundayStart.StartOfWeek(wednesday), sunday) {
+		t.Fail()
+	}
+	if !DateEqual(sundayStart.EndOfWeek(wednesday), DateFromYYYYMMDD(2019, time.February, 9)) {
+		t.Fail()
+	}
+}
+
+func TestWeeklyDueDate(t *testing.T) {
+
+	sunday := DateFromYYYYMMDD(2019, time.February, 3)
+	wednesday := DateFromYYYYMMDD(2019, time.February, 6)
+	friday := DateFromYYYYMMDD(2019, time.February, 8)
+
+	if !DateEqual(DefaultCalendar.WeeklyDueDate(wednesday), DateFromYYYYMMDD(2019, time.February, 9)) {
+		t.Fail()
+	}
+	if !DateEqual(DefaultCalendar.WeeklyDueDate(sunday), sunday) {
+		t.Fail()
+	}
+
+	// a working day with a friday/saturday weekend
+	if !DateEqual(sundayStart.WeeklyDueDate(sunday), friday) {
+		t.Fail()
+	}
+	if !DateEqual(sundayStart.WeeklyDueDate(friday), friday) {
+		t.Fail()
+	}
+
+	// no weekend left in the week, so the last day of it
+	noWeekend := Calendar{WeekStart: time.Monday}
+	if !DateEqual(noWeekend.WeeklyDueDate(wednesday), DateFromYYYYMMDD(2019, time.February, 10)) {
+		t.Fail()
+	}
+}
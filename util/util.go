@@ -5,9 +5,9 @@ import (
 	"time"
 )
 
-// might depend on locale
+// IsWeekendDay uses the default calendar, see Calendar for other parts of the world
 func IsWeekendDay(day time.Weekday) bool {
-	return day == time.Saturday || day == time.Sunday
+	return DefaultCalendar.IsWeekendDay(day)
 }
 
 func DateFromYYYYMMDD(yyyy int, mm time.Month, dd int) time.Time {
@@ -22,3 +22,25 @@ func StrDateFromTime(t time.Time) string {
 	y, m, d := t.Date()
 	return fmt.Sprintf("%d-%02d-%02d", y, m, d)
 }
+
+// Location loads an IANA timezone, falling back to UTC when it's unknown
+func Location(name string) *time.Location {
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// DaysBetween counts the calendar days from one date to the other, ignoring the time of day
+func DaysBetween(from, to time.Time) int {
+
+	y, m, d := from.Date()
+	start := DateFromYYYYMMDD(y, m, d)
+	y, m, d = to.Date()
+	end := DateFromYYYYMMDD(y, m, d)
+
+	return int(end.Sub(start).Hours() / 24)
+}
@@ -55,3 +55,23 @@ func TestStrDateFromTime(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestDaysBetween(t *testing.T) {
+
+	from := time.Date(2019, time.February, 27, 23, 0, 0, 0, time.UTC)
+	to := time.Date(2019, time.March, 1, 1, 0, 0, 0, time.UTC)
+	if DaysBetween(from, to) != 2 || DaysBetween(to, from) != -2 {
+		t.Fail()
+	}
+}
+
+func TestLocation(t *testing.T) {
+
+	if Location("Europe/Amsterdam").String() != "Europe/Amsterdam" {
+		t.Fail()
+	}
+
+	if Location("Middle/Earth") != time.UTC || Location("") != time.UTC {
+		t.Fail()
+	}
+}